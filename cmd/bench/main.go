@@ -5,13 +5,19 @@ import (
 	"errors"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/nspcc-dev/neo-bench/internal"
+	"github.com/nspcc-dev/neo-go/pkg/neorpc/result"
+	"github.com/spf13/viper"
 )
 
 // Main steps for testing are:
@@ -23,6 +29,21 @@ import (
 // - start sending txes to the node
 // - measure how much TX could be sent
 
+// bench holds state shared by all benchmark runs.
+type bench struct {
+	v               *viper.Viper
+	client          *internal.RPCClient
+	version         *result.Version
+	versionStr      string
+	msPerBlock      int
+	mempoolOOMDelay time.Duration
+	keys            *internal.Keys
+	sinks           []internal.ResultSink
+	exp             *internal.Exporter
+	// prepared is set after the chain is prepared by the first run.
+	prepared bool
+}
+
 func main() {
 	v := internal.InitSettings()
 
@@ -32,99 +53,306 @@ func main() {
 	defer cancel()
 
 	var (
-		workers         = v.GetInt("workers")
-		rate            int
-		msPerBlock      int
-		mempoolOOMDelay time.Duration
-		threshold       time.Duration
-		dump            *internal.Dump
-		desc            = v.GetString("desc")
-		timeLimit       = v.GetDuration("timeLimit")
-		mode            = internal.BenchMode(v.GetString("mode"))
-		client          *internal.RPCClient
-		disableStats    = v.GetBool("disable-stats")
+		desc  = v.GetString("desc")
+		out   = v.GetString("out")
+		cells = matrix(v)
+		b     = &bench{v: v}
+		err   error
 	)
 
-	if mode == internal.ModeRate {
-		rate = v.GetInt("rateLimit")
-		threshold = time.Duration(time.Second.Nanoseconds() / int64(rate) * int64(workers))
+	var maxWorkers int
+	for _, c := range cells {
+		maxWorkers = max(maxWorkers, c.Workers)
 	}
 
-	client = internal.NewRPCClient(v, workers)
-	version, err := client.GetVersion(ctx)
+	b.client = internal.NewRPCClient(v, maxWorkers)
+	b.version, err = b.client.GetVersion(ctx)
 	if err != nil {
 		log.Fatalf("could not receive RPC Node version: %v", err)
 	}
-	msPerBlock = version.Protocol.MillisecondsPerBlock
-	if msPerBlock > 1000 {
-		mempoolOOMDelay = time.Duration(msPerBlock) * time.Millisecond / 50
+	b.msPerBlock = b.version.Protocol.MillisecondsPerBlock
+	if b.msPerBlock > 1000 {
+		b.mempoolOOMDelay = time.Duration(b.msPerBlock) * time.Millisecond / 50
 	} else {
-		mempoolOOMDelay = time.Duration(msPerBlock) * time.Millisecond / 10
+		b.mempoolOOMDelay = time.Duration(b.msPerBlock) * time.Millisecond / 10
 	}
 
 	reg := regexp.MustCompile(`[^\w.-]+`)
-	versionStr := strings.Trim(reg.ReplaceAllString(version.UserAgent, "_"), "_")
-	log.Println("Run benchmark for " + desc + " :: " + versionStr)
+	b.versionStr = strings.Trim(reg.ReplaceAllString(b.version.UserAgent, "_"), "_")
+	log.Println("Run benchmark for " + desc + " :: " + b.versionStr)
 
 	//raising the limits. Some performance gains were achieved with the + workers count (not a lot).
-	runtime.GOMAXPROCS(runtime.NumCPU() + workers)
+	runtime.GOMAXPROCS(runtime.NumCPU() + maxWorkers)
+
+	for _, url := range v.GetStringSlice("sink-influx") {
+		b.sinks = append(b.sinks, internal.NewInfluxSink(url, v.GetString("sink-influx-token")))
+	}
+	for _, url := range v.GetStringSlice("sink-webhook") {
+		b.sinks = append(b.sinks, internal.NewWebhookSink(url))
+	}
+
+	b.keys, err = internal.LoadKeys(v.GetString("keys"))
+	if err != nil {
+		log.Fatalf("could not load keys: %v", err)
+	}
+
+	if addr := v.GetString("exporter"); addr != "" {
+		b.exp = internal.NewExporter(addr)
+		go b.exp.Run(ctx)
+	}
+
+	if len(cells) == 1 && !matrixRequested(v) {
+		b.run(ctx, cells[0], out, 0)
+		return
+	}
+
+	// Combined summary is written even if some runs are interrupted.
+	var mrep internal.MatrixReport
+	defer func() {
+		f, err := os.Create(out)
+		if err != nil {
+			log.Fatalf("could not open report: %v", err)
+		}
+		if _, err := mrep.WriteTo(f); err != nil {
+			log.Fatalf("could not write result: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("could not close report: %v", err)
+		}
+	}()
+
+	for i, cell := range cells {
+		if i > 0 {
+			log.Printf("Wait for the chain to settle before %s run", cell)
+			if err := internal.WaitSettled(ctx, b.client, b.msPerBlock, v.GetDuration("settle-timeout")); err != nil {
+				log.Printf("Continue anyway: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(v.GetDuration("cooldown")):
+			}
+		}
+		if ctx.Err() != nil {
+			log.Printf("Matrix runs interrupted")
+			return
+		}
+
+		log.Printf("Matrix run %d/%d: %s", i+1, len(cells), cell)
+		report := cell.ReportPath(out)
+		sum := b.run(ctx, cell, report, uint64(i))
+		mrep.Add(cell, report, sum)
+	}
+}
+
+// matrix returns cells of matrix runs, it's a single cell of --mode, --workers
+// and --rateLimit if matrix isn't requested.
+func matrix(v *viper.Viper) []internal.MatrixCell {
+	modes := []internal.BenchMode{internal.BenchMode(v.GetString("mode"))}
+	if ms := v.GetStringSlice("matrix-mode"); len(ms) != 0 {
+		modes = modes[:0]
+		for _, m := range ms {
+			modes = append(modes, internal.BenchMode(m))
+		}
+	}
+
+	workers := v.GetIntSlice("matrix-workers")
+	if len(workers) == 0 {
+		workers = []int{v.GetInt("workers")}
+	}
+
+	rates := v.GetIntSlice("matrix-rate")
+	if len(rates) == 0 {
+		rates = []int{v.GetInt("rateLimit")}
+	}
+
+	return internal.NewMatrix(modes, workers, rates)
+}
+
+func matrixRequested(v *viper.Viper) bool {
+	return len(v.GetStringSlice("matrix-mode")) != 0 ||
+		len(v.GetIntSlice("matrix-workers")) != 0 ||
+		len(v.GetIntSlice("matrix-rate")) != 0
+}
+
+// run performs a single benchmark run with the load profile of the cell and
+// writes its report to out. Transactions generated on the fly use the seed
+// shifted by seedShift, so that runs don't repeat each other.
+func (b *bench) run(parent context.Context, cell internal.MatrixCell, out string, seedShift uint64) internal.ResultSummary {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		v            = b.v
+		workers      = cell.Workers
+		mode         = cell.Mode
+		rate         = cell.Rate
+		threshold    time.Duration
+		dump         *internal.Dump
+		desc         = v.GetString("desc")
+		timeLimit    = v.GetDuration("timeLimit")
+		client       = b.client
+		disableStats = v.GetBool("disable-stats")
+		err          error
+	)
+
+	if mode == internal.ModeRate {
+		threshold = time.Duration(time.Second.Nanoseconds() / int64(rate) * int64(workers))
+	}
+
+	tags := resultTags(v, b.version, cell)
 
 	rep := internal.NewReporter(
 		internal.ReportMode(mode),
-		internal.ReportDescription(desc+" :: "+versionStr),
+		internal.ReportDescription(desc+" :: "+b.versionStr),
 		internal.ReportTimeLimit(timeLimit),
 		internal.ReportWorkersCount(workers),
 		internal.ReportRate(rate),
-		internal.ReportDefaultMSPerBlock(msPerBlock))
+		internal.ReportDefaultMSPerBlock(b.msPerBlock),
+		internal.ReportValidatorsCount(int(b.version.Protocol.ValidatorsCount)),
+		internal.ReportTags(tags),
+		internal.ReportSinks(b.sinks...))
 
-	out, err := os.Create(v.GetString("out"))
+	f, err := os.Create(out)
 	if err != nil {
 		log.Fatalf("could not open report: %v", err)
 	}
 
 	defer func() {
 		log.Println("try to write profile")
-		if _, err := rep.WriteTo(out); err != nil {
+		if _, err := rep.WriteTo(f); err != nil {
 			log.Fatalf("could not write result: %v", err)
 		}
 
-		if err := out.Close(); err != nil {
+		if err := f.Close(); err != nil {
 			log.Fatalf("could not close report: %v", err)
 		}
+
+		pushCtx, cancel := context.WithTimeout(context.Background(), internal.DefaultTimeout)
+		defer cancel()
+		if err := rep.Push(pushCtx); err != nil {
+			log.Printf("could not push results: %v", err)
+		}
 	}()
+	benchStart := time.Now()
 	if !disableStats {
 		statsPeriod := time.Second
 
-		ds, err := internal.NewStats(ctx,
-			internal.StatEnableLogger(),
-			internal.StatPeriod(statsPeriod),
-			internal.StatCriteria([]string{"stats"}),
-			internal.StatListVerifier(func(list []container.Summary) error {
-				if len(list) == 0 {
-					return errors.New("containers not found by criteria")
-				}
+		var ds internal.DockerStater
+		if procs := v.GetStringSlice("stats-proc"); len(procs) != 0 {
+			ds, err = internal.NewProcStats(ctx,
+				internal.StatEnableLogger(),
+				internal.StatPeriod(statsPeriod),
+				internal.StatProcesses(procs))
+		} else {
+			ds, err = internal.NewStats(ctx,
+				internal.StatEnableLogger(),
+				internal.StatPeriod(statsPeriod),
+				internal.StatCriteria([]string{"stats"}),
+				internal.StatListVerifier(func(list []container.Summary) error {
+					if len(list) == 0 {
+						return errors.New("containers not found by criteria")
+					}
 
-				return nil
-			}))
+					return nil
+				}))
+		}
 
 		if err != nil {
 			log.Fatalf("could not create docker stats grabber: %v", err)
 		}
 
-		statsStart := time.Now()
 		// Run stats worker:
-		go ds.Run(ctx, func(cpu, mem float64) {
-			rep.UpdateRes(statsStart, cpu, mem)
-			log.Printf("CPU: %0.3f%%, Mem: %0.3fMB", cpu, mem)
+		go ds.Run(ctx, func(total internal.ResourceUsage, per []internal.ContainerStat) {
+			rep.UpdateRes(benchStart, total, per)
+			log.Printf("CPU: %0.3f%%, Mem: %0.3fMB, Net RX/TX: %0.3fMB/%0.3fMB, Disk R/W: %0.3fMB/%0.3fMB",
+				total.CPU, total.Mem, total.NetRx, total.NetTx, total.BlkRead, total.BlkWrite)
+		})
+	}
+
+	if endpoints := v.GetStringSlice("metrics"); len(endpoints) != 0 {
+		ms, err := internal.NewMetricsScraper(ctx,
+			internal.MetricsEnableLogger(),
+			internal.MetricsEndpoints(endpoints),
+			internal.MetricsNames(v.GetStringSlice("metrics-name")))
+		if err != nil {
+			log.Fatalf("could not create metrics scraper: %v", err)
+		}
+
+		// Run metrics worker:
+		go ms.Run(ctx, func(endpoint string, values map[string]float64) {
+			rep.UpdateMetrics(benchStart, endpoint, values)
 		})
 	}
 
+	var chaos *internal.Chaos
+	if actions, _ := v.Get("chaos").([]internal.ChaosAction); len(actions) != 0 {
+		chaos, err = internal.NewChaos(ctx,
+			internal.ChaosEnableLogger(),
+			internal.ChaosActions(actions))
+		if err != nil {
+			log.Fatalf("could not prepare chaos actions: %v", err)
+		}
+		defer chaos.Close()
+	}
+
+	var prof internal.Profiler
+	if endpoints := v.GetStringSlice("pprof"); len(endpoints) != 0 {
+		prof, err = internal.NewProfiler(
+			internal.ProfileEndpoints(endpoints),
+			internal.ProfileKinds(v.GetStringSlice("pprof-profiles")),
+			internal.ProfileAt(v.Get("pprof-at").([]time.Duration)),
+			internal.ProfileCPUDuration(v.GetDuration("pprof-cpu-duration")),
+			internal.ProfileOutput(strings.TrimSuffix(out, filepath.Ext(out))))
+		if err != nil {
+			log.Fatalf("could not create profiler: %v", err)
+		}
+	}
+
+	// Transactions generated on the fly get ValidUntilBlock of the chain
+	// height, so generation starts after the chain is prepared.
+	var generate func()
 	if in := v.GetString("in"); in != "" {
 		dump = internal.ReadDump(in)
 	} else {
-		log.Fatalf("Transactions dump file wasn't specified.")
+		opts, err := internal.NewGenerateOptions(v, b.keys.Sender)
+		if err != nil {
+			log.Fatalf("could not prepare transactions generation: %v", err)
+		}
+		// Senders are the same for all runs, only transactions differ.
+		opts.Seed += seedShift
+
+		dump = internal.NewStreamDump(opts, v.GetUint64("gen-buffer"))
+		generate = func() {
+			vub, err := newVUBTracker(ctx, client, b.version.Protocol.MaxValidUntilBlockIncrement)
+			if err != nil {
+				log.Fatalf("could not fetch block count: %v", err)
+			}
+			internal.GenerateStream(ctx, dump, vub, rep.UpdateGenRate)
+		}
 	}
 
+	var (
+		rpsReporter  = rep.UpdateRPS
+		tpsReporter  = rep.UpdateTPS
+		sendReporter func(error)
+	)
+	if exp := b.exp; exp != nil {
+		rpsReporter = func(v float64) {
+			rep.UpdateRPS(v)
+			exp.UpdateRPS(v)
+		}
+		tpsReporter = func(deltaTime uint64, txCount int, v float64) {
+			rep.UpdateTPS(deltaTime, txCount, v)
+			exp.UpdateTPS(deltaTime, txCount, v)
+		}
+		sendReporter = exp.UpdateSend
+	}
+
+	// Transactions are known only now, tags are shared with the reporter.
+	tags["tx_type"] = dump.BenchOptions.TransferType
+	tags["senders"] = strconv.Itoa(len(dump.BenchOptions.Senders))
+	tags["receivers"] = strconv.Itoa(dump.BenchOptions.ToCount)
+
 	wrk, err := internal.NewWorkers(
 		internal.WorkerDump(dump),
 		internal.WorkerMode(mode),
@@ -134,19 +362,27 @@ func main() {
 		internal.WorkerTimeLimit(timeLimit),
 		internal.WorkerThreshold(threshold),
 		internal.WorkerBlockchainClient(client),
-		internal.WorkerMempoolOOMDelay(mempoolOOMDelay),
-		internal.WorkerRPSReporter(rep.UpdateRPS),
-		internal.WorkerTPSReporter(rep.UpdateTPS),
+		internal.WorkerMempoolOOMDelay(b.mempoolOOMDelay),
+		internal.WorkerRPSReporter(rpsReporter),
+		internal.WorkerTPSReporter(tpsReporter),
+		internal.WorkerBlockReporter(rep.UpdateConsensus),
+		internal.WorkerSendReporter(sendReporter),
 		internal.WorkerErrReporter(rep.UpdateErr),
 		internal.WorkerCntReporter(rep.UpdateCnt),
 	)
 
 	if err != nil {
 		log.Println(err)
-		return
+		return rep.Summary()
 	}
 
-	wrk.Prepare(ctx, v.GetBool("vote"), dump.BenchOptions)
+	if !b.prepared {
+		wrk.Prepare(ctx, b.keys.Committee, v.GetBool("vote"), dump.BenchOptions)
+		b.prepared = true
+	}
+	if generate != nil {
+		generate()
+	}
 
 	log.Printf("fetch current block count")
 	blk, err := client.GetLastBlock(ctx)
@@ -165,16 +401,100 @@ func main() {
 		if blk.Index > startBlockIndex {
 			break
 		}
-		time.Sleep(time.Duration(msPerBlock) * time.Millisecond / 4)
+		time.Sleep(time.Duration(b.msPerBlock) * time.Millisecond / 4)
 	}
 	if blk.Index == startBlockIndex {
 		log.Fatalf("Timeout waiting for a new empty block")
 	}
 
 	log.Printf("Started test from block = %v at unix time = %v", blk.Index, blk.Timestamp)
+	log.Printf("%s %d", internal.BenchStartLog, benchStart.UnixMilli())
 
 	go wrk.Parser(ctx, blk)
 	go wrk.Sender(ctx)
+	if prof != nil {
+		go prof.Run(ctx, time.Now())
+	}
+	if chaos != nil {
+		chaosDone := make(chan struct{})
+		go func() {
+			defer close(chaosDone)
+			chaos.Run(ctx, time.Now(), func(a internal.ChaosAction, err error) {
+				rep.UpdateChaos(benchStart, a, err)
+			})
+		}()
+		// Containers are restored before the report is written and the next
+		// matrix run starts.
+		defer func() {
+			cancel()
+			<-chaosDone
+		}()
+	}
 
 	wrk.Wait()
+	return rep.Summary()
+}
+
+// resultTags returns tags describing node version, benchmark configuration and
+// the bench build attached to the results pushed to sinks.
+func resultTags(v *viper.Viper, version *result.Version, cell internal.MatrixCell) map[string]string {
+	tags := map[string]string{
+		"node_version": version.UserAgent,
+		"validators":   strconv.Itoa(int(version.Protocol.ValidatorsCount)),
+		"ms_per_block": strconv.Itoa(version.Protocol.MillisecondsPerBlock),
+		"desc":         v.GetString("desc"),
+		"mode":         cell.Mode.String(),
+		"workers":      strconv.Itoa(cell.Workers),
+		"rate":         strconv.Itoa(cell.Rate),
+		"time_limit":   v.GetDuration("timeLimit").String(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				tags["bench_revision"] = s.Value
+			}
+		}
+	}
+
+	for _, tag := range v.GetStringSlice("result-tag") {
+		k, val, _ := strings.Cut(tag, "=")
+		tags[k] = val
+	}
+	return tags
+}
+
+// newVUBTracker returns function that returns ValidUntilBlock for transactions
+// generated on the fly. It's based on the current chain height which is
+// periodically updated in background.
+func newVUBTracker(ctx context.Context, client *internal.RPCClient, increment uint32) (func() uint32, error) {
+	if increment == 0 {
+		increment = internal.DefaultVUBIncrement
+	}
+
+	count, err := client.GetBlockCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var vub atomic.Uint32
+	vub.Store(uint32(count) - 1 + increment)
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if count, err := client.GetBlockCount(ctx); err == nil {
+					vub.Store(uint32(count) - 1 + increment)
+				}
+			}
+		}
+	}()
+
+	return vub.Load, nil
 }
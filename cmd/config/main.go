@@ -9,6 +9,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +18,7 @@ import (
 	"carvel.dev/ytt/pkg/cmd/template"
 	"carvel.dev/ytt/pkg/cmd/ui"
 	"carvel.dev/ytt/pkg/files"
+	"github.com/nspcc-dev/neo-bench/internal"
 	"github.com/nspcc-dev/neo-go/pkg/config"
 	"gopkg.in/yaml.v3"
 )
@@ -33,6 +36,8 @@ var (
 	sharpTemplateFile = flag.String("sharp-template", "", "configuration template file for C# node")
 	sharpDB           = flag.String("sharp-db", "LevelDBStore", "database for C# node")
 	msPerBlock        = flag.Int("msPerBlock", 0, "time per block in milliseconds")
+	validators        = flag.String("validators", "4,7", "comma-separated list of validator counts to generate configurations for, single node ones are generated along with every network")
+	overridesFile     = flag.String("overrides", "", "YAML file with global, per-role and per-node configuration overrides")
 )
 
 func main() {
@@ -49,52 +54,99 @@ func main() {
 		}
 	}()
 
-	if templateFile := *goTemplateFile; templateFile != "" {
-		err := convertTemplateToPlain(templateFile, tempDir, 4)
+	counts, err := parseValidators(*validators)
+	if err != nil {
+		log.Fatalf("invalid validators: %v", err)
+	}
+	overrides, err := loadOverrides(*overridesFile)
+	if err != nil {
+		log.Fatalf("failed to load overrides: %v", err)
+	}
+	data, err := loadTemplateData(configPath)
+	if err != nil {
+		log.Fatalf("failed to load template data: %v", err)
+	}
+
+	for _, count := range counts {
+		nodes, err := data.validators(count)
 		if err != nil {
-			log.Fatalf("failed to call ytt for Go template: %v", err)
+			log.Fatalf("failed to prepare %d validators: %v", count, err)
 		}
-		err = generateGoConfig(tempDir+"/"+templateFile, *goDB, "")
+		dataFile, err := data.write(tempDir, nodes)
 		if err != nil {
-			log.Fatalf("failed to generate Go configurations: %v", err)
+			log.Fatalf("failed to write template data: %v", err)
 		}
 
-		err = convertTemplateToPlain(templateFile, tempDir, 7)
-		if err != nil {
-			log.Fatalf("failed to call ytt for Go template: %v", err)
+		var (
+			suffix  = internal.ConfigSuffix(count)
+			configs []nodeConfig
+		)
+		if templateFile := *goTemplateFile; templateFile != "" {
+			err := convertTemplateToPlain(configPath+templateFile, dataFile, tempDir)
+			if err != nil {
+				log.Fatalf("failed to call ytt for Go template: %v", err)
+			}
+			cfgs, err := generateGoConfig(tempDir+"/"+templateFile, *goDB, suffix, overrides.Go)
+			if err != nil {
+				log.Fatalf("failed to generate Go configurations: %v", err)
+			}
+			configs = append(configs, cfgs...)
 		}
-		err = generateGoConfig(tempDir+"/"+templateFile, *goDB, ".7")
-		if err != nil {
-			log.Fatalf("failed to generate Go configurations: %v", err)
+		if templateFile := *sharpTemplateFile; templateFile != "" {
+			err := convertTemplateToPlain(configPath+templateFile, dataFile, tempDir)
+			if err != nil {
+				log.Fatalf("failed to call ytt for C# template: %v", err)
+			}
+			cfgs, err := generateSharpConfig(tempDir+"/"+templateFile, *sharpDB, suffix, overrides.Sharp)
+			if err != nil {
+				log.Fatalf("failed to generate C# configurations: %v", err)
+			}
+			configs = append(configs, cfgs...)
 		}
-	}
-	if templateFile := *sharpTemplateFile; templateFile != "" {
-		err := convertTemplateToPlain(templateFile, tempDir, 4)
-		if err != nil {
-			log.Fatalf("failed to call ytt for C# template: %v", err)
+
+		if err := validateConfigs(configs); err != nil {
+			log.Fatalf("invalid configurations of %d validators network:\n%v", count, err)
 		}
-		err = generateSharpConfig(tempDir+"/"+templateFile, *sharpDB, "")
-		if err != nil {
-			log.Fatalf("failed to generate C# configurations: %v", err)
+		for _, cfg := range configs {
+			if err := cfg.write(); err != nil {
+				log.Fatalf("failed to write configuration: %v", err)
+			}
 		}
+		if !internal.HasDefaultCommittee(count) {
+			if err := writeKeysConfig(configPath, nodes); err != nil {
+				log.Fatalf("failed to write keys config: %v", err)
+			}
+		}
+	}
+}
 
-		err = convertTemplateToPlain(templateFile, tempDir, 7)
+// parseValidators parses comma-separated list of validator counts. Networks
+// of 1 and 4 validators share configuration files (see ConfigSuffix), single
+// node configurations are generated along with every network, so 1 isn't
+// accepted to not overwrite 4 validators ones.
+func parseValidators(s string) ([]int, error) {
+	var res []int
+	for v := range strings.SplitSeq(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
 		if err != nil {
-			log.Fatalf("failed to call ytt for C# template: %v", err)
+			return nil, err
 		}
-		err = generateSharpConfig(tempDir+"/"+templateFile, *sharpDB, ".7")
-		if err != nil {
-			log.Fatalf("failed to generate C# configurations: %v", err)
+		switch {
+		case n == 1:
+			return nil, errors.New("single node configurations are generated with every network, 1 validator isn't a separate one")
+		case n < 1:
+			return nil, fmt.Errorf("invalid validators count: %d", n)
+		case slices.Contains(res, n):
+			return nil, fmt.Errorf("duplicate validators count: %d", n)
 		}
+		res = append(res, n)
 	}
+	return res, nil
 }
 
-func convertTemplateToPlain(templatePath string, tempDir string, nodeCount int) error {
-	filePath := configPath + templatePath
-	dataPath := configPath + templateDataFile
+func convertTemplateToPlain(templatePath string, dataPath string, tempDir string) error {
 	opts := template.NewOptions()
-	opts.DataValuesFlags.KVsFromYAML = []string{"validators_count=" + strconv.FormatInt(int64(nodeCount), 10)}
-	inFiles, err := files.NewSortedFilesFromPaths([]string{filePath, dataPath}, files.SymlinkAllowOpts{})
+	inFiles, err := files.NewSortedFilesFromPaths([]string{templatePath, dataPath}, files.SymlinkAllowOpts{})
 	if err != nil {
 		return err
 	}
@@ -111,12 +163,13 @@ func convertTemplateToPlain(templatePath string, tempDir string, nodeCount int)
 	return nil
 }
 
-func generateGoConfig(templatePath, database, suffix string) error {
+func generateGoConfig(templatePath, database, suffix string, overrides NodeOverrides) ([]nodeConfig, error) {
 	f, err := os.Open(templatePath)
 	if err != nil {
-		return fmt.Errorf("failed to open template: %w", err)
+		return nil, fmt.Errorf("failed to open template: %w", err)
 	}
 	defer f.Close()
+	var res []nodeConfig
 	decoder := yaml.NewDecoder(bufio.NewReader(f))
 	for i := 0; ; i++ {
 		var template config.Config
@@ -125,40 +178,40 @@ func generateGoConfig(templatePath, database, suffix string) error {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("unable to decode node template #%d: %w", i, err)
+			return nil, fmt.Errorf("unable to decode node template #%d: %w", i, err)
 		}
 		template.ApplicationConfiguration.DBConfiguration.Type = database
 		if msPerBlock != nil && *msPerBlock > 0 {
 			template.ProtocolConfiguration.TimePerBlock = time.Duration(*msPerBlock) * time.Millisecond
 		}
 		var configFile string
+		role := roleValidator
 		nodeName, err := nodeNameFromSeedList(template.ApplicationConfiguration.P2P.Addresses, template.ProtocolConfiguration.SeedList)
 		if err != nil {
 			// it's an RPC node then
 			configFile = rpcConfigPath + "go.protocol" + suffix + ".yml"
+			role = roleRPC
 			template.ApplicationConfiguration.Consensus.UnlockWallet.Path = ""
 			template.ApplicationConfiguration.Consensus.Enabled = false
 		} else {
 			configFile = configPath + "go.protocol.privnet." + nodeName + suffix + ".yml"
 		}
-		bytes, err := yaml.Marshal(template)
-		if err != nil {
-			return fmt.Errorf("could not marshal config for node #%s: %w", nodeName, err)
-		}
-		err = os.WriteFile(configFile, bytes, 0644)
+		err = applyOverrides(&template, overrides.patches(role, nodeName))
 		if err != nil {
-			return fmt.Errorf("could not write config for node #%s: %w", nodeName, err)
+			return nil, fmt.Errorf("could not override config for node #%s: %w", nodeName, err)
 		}
+		res = append(res, nodeConfig{path: configFile, name: nodeName, role: role, goCfg: &template})
 	}
-	return nil
+	return res, nil
 }
 
-func generateSharpConfig(templatePath, storageEngine, suffix string) error {
+func generateSharpConfig(templatePath, storageEngine, suffix string, overrides NodeOverrides) ([]nodeConfig, error) {
 	f, err := os.Open(templatePath)
 	if err != nil {
-		return fmt.Errorf("failed to open template: %w", err)
+		return nil, fmt.Errorf("failed to open template: %w", err)
 	}
 	defer f.Close()
+	var res []nodeConfig
 	decoder := yaml.NewDecoder(bufio.NewReader(f))
 	for i := 0; ; i++ {
 		var template SharpTemplate
@@ -167,29 +220,84 @@ func generateSharpConfig(templatePath, storageEngine, suffix string) error {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("unable to decode node template #%d: %w", i, err)
+			return nil, fmt.Errorf("unable to decode node template #%d: %w", i, err)
 		}
 		template.ApplicationConfiguration.Storage.Engine = storageEngine
 		if msPerBlock != nil && *msPerBlock > 0 {
 			template.ProtocolConfiguration.MillisecondsPerBlock = *msPerBlock
 		}
 		var configFile string
+		role := roleValidator
 		nodeName, err := nodeNameFromSeedList([]string{":" + strconv.Itoa(int(template.ApplicationConfiguration.P2P.Port))}, template.ProtocolConfiguration.SeedList)
 		if err != nil {
 			// it's an RPC node then
 			configFile = rpcConfigPath + "sharp.config" + suffix + ".json"
+			role = roleRPC
 			template.ApplicationConfiguration.UnlockWallet = UnlockWallet{}
+			if template.Plugins.DBFTPlugin != nil {
+				template.Plugins.DBFTPlugin.PluginConfiguration.AutoStart = false
+			}
 		} else {
 			configFile = configPath + "sharp.config." + nodeName + suffix + ".json"
 		}
-		err = writeJSON(configFile, SharpConfig(template))
+		err = applyOverrides(&template, overrides.patches(role, nodeName))
+		if err != nil {
+			return nil, fmt.Errorf("could not override config for node #%s: %w", nodeName, err)
+		}
+		res = append(res, nodeConfig{
+			path: configFile,
+			name: nodeName,
+			role: role,
+			sharpCfg: &SharpConfig{
+				ApplicationConfiguration: template.ApplicationConfiguration,
+				ProtocolConfiguration:    template.ProtocolConfiguration,
+				Extra:                    template.Extra,
+			},
+			sharpPlugins: &template.Plugins,
+		})
+	}
+	return res, nil
+}
+
+// write stores the configuration to its file.
+func (n nodeConfig) write() error {
+	if n.goCfg != nil {
+		bytes, err := yaml.Marshal(n.goCfg)
+		if err != nil {
+			return fmt.Errorf("could not marshal config for node #%s: %w", n.name, err)
+		}
+		err = os.WriteFile(n.path, bytes, 0644)
+		if err != nil {
+			return fmt.Errorf("could not write config for node #%s: %w", n.name, err)
+		}
+		return nil
+	}
+	err := writeJSON(n.path, n.sharpCfg)
+	if err != nil {
+		return fmt.Errorf("could not write JSON config file for node #%s: %w", n.name, err)
+	}
+	if p := n.sharpPlugins.RpcServer; p != nil {
+		err = writeJSON(sharpPluginConfigPath(n.path, "rpc"), p)
 		if err != nil {
-			return fmt.Errorf("could not write JSON config file for node #%s: %w", nodeName, err)
+			return fmt.Errorf("could not write RpcServer config file for node #%s: %w", n.name, err)
+		}
+	}
+	if p := n.sharpPlugins.DBFTPlugin; p != nil {
+		err = writeJSON(sharpPluginConfigPath(n.path, "dbft"), p)
+		if err != nil {
+			return fmt.Errorf("could not write DBFTPlugin config file for node #%s: %w", n.name, err)
 		}
 	}
 	return nil
 }
 
+// sharpPluginConfigPath returns path of the plugin configuration stored next
+// to the node configuration, e.g. sharp.rpc.one.json for sharp.config.one.json.
+func sharpPluginConfigPath(configFile, plugin string) string {
+	dir, file := filepath.Split(configFile)
+	return dir + strings.Replace(file, "sharp.config", "sharp."+plugin, 1)
+}
+
 func writeJSON(path string, obj any) error {
 	bytes, err := json.Marshal(obj)
 	if err != nil {
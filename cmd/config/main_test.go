@@ -0,0 +1,86 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nspcc-dev/neo-bench/internal"
+)
+
+func TestParseValidators(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []int
+	}{
+		{"4,7", []int{4, 7}},
+		{"4, 5,7", []int{4, 5, 7}},
+		{"1", nil},
+		{"1,4", nil},
+		{"4,4", nil},
+		{"0", nil},
+		{"four", nil},
+	}
+	for _, tc := range tests {
+		res, err := parseValidators(tc.s)
+		if (err == nil) != (tc.want != nil) || !slices.Equal(res, tc.want) {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.s, tc.want, res, err)
+		}
+	}
+}
+
+// TestGenerateConfigs generates configurations of networks sharing the data
+// directory from the repository templates, every network should get files of
+// its own.
+func TestGenerateConfigs(t *testing.T) {
+	data, err := loadTemplateData(templateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make(map[string]int)
+	for _, count := range []int{4, 5, 7} {
+		nodes, err := data.validators(count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		dataFile, err := data.write(dir, nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var configs []nodeConfig
+		for _, tmpl := range []string{"go.protocol.template.yml", "sharp.protocol.template.yml"} {
+			if err := convertTemplateToPlain(templateDir+tmpl, dataFile, dir); err != nil {
+				t.Fatal(err)
+			}
+		}
+		suffix := internal.ConfigSuffix(count)
+		goCfgs, err := generateGoConfig(dir+"/go.protocol.template.yml", "leveldb", suffix, NodeOverrides{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sharpCfgs, err := generateSharpConfig(dir+"/sharp.protocol.template.yml", "LevelDBStore", suffix, NodeOverrides{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs = append(append(configs, goCfgs...), sharpCfgs...)
+
+		// Validators, single and RPC node of both implementations.
+		if len(configs) != 2*(count+2) {
+			t.Fatalf("%d validators: unexpected %d configurations", count, len(configs))
+		}
+		if err := validateConfigs(configs); err != nil {
+			t.Fatalf("%d validators: %v", count, err)
+		}
+		for _, c := range configs {
+			if other, ok := paths[c.path]; ok {
+				t.Fatalf("%s is generated for %d and %d validators", c.path, other, count)
+			}
+			paths[c.path] = count
+			if c.protocol().ValidatorsCount != count && c.name != singleNodeName {
+				t.Fatalf("%s: unexpected validators count %d", c.path, c.protocol().ValidatorsCount)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/nspcc-dev/neo-bench/internal"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+	"github.com/nspcc-dev/neo-go/pkg/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+// reservedNodes is the number of nodes_info entries preceding validators: the
+// single node and the RPC node.
+const reservedNodes = 2
+
+// nodeInfo is an entry of nodes_info list of the template data values.
+type nodeInfo struct {
+	NodeName           string `yaml:"node_name"`
+	NodePort           int    `yaml:"node_port"`
+	NodeRPCPort        int    `yaml:"node_rpc_port"`
+	NodeMonitoringPort int    `yaml:"node_monitoring_port"`
+	NodePprofPort      int    `yaml:"node_pprof_port"`
+	NodePrometheusPort int    `yaml:"node_prometheus_port"`
+	ValidatorHash      string `yaml:"validator_hash"`
+	WalletPassword     string `yaml:"wallet_password"`
+}
+
+// templateData holds data values of the templates, nodes_info list is extended
+// to the requested number of validators with their keys taken from wallets.
+type templateData struct {
+	// dir is a directory with template data and validator wallets.
+	dir    string
+	values map[string]any
+	nodes  []nodeInfo
+	// keys caches validator public keys by node name.
+	keys map[string]*keys.PublicKey
+}
+
+func loadTemplateData(dir string) (*templateData, error) {
+	data, err := os.ReadFile(filepath.Join(dir, templateDataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		d     = &templateData{dir: dir, keys: make(map[string]*keys.PublicKey)}
+		nodes struct {
+			NodesInfo []nodeInfo `yaml:"nodes_info"`
+		}
+	)
+	if err := yaml.Unmarshal(data, &d.values); err != nil {
+		return nil, fmt.Errorf("could not decode template data: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("could not decode nodes info: %w", err)
+	}
+	if len(nodes.NodesInfo) < reservedNodes {
+		return nil, fmt.Errorf("nodes info should start with %d reserved entries", reservedNodes)
+	}
+	d.nodes = nodes.NodesInfo
+	return d, nil
+}
+
+// write stores data values for the network of the given validators to the
+// given directory and returns the file path.
+func (d *templateData) write(dir string, nodes []nodeInfo) (string, error) {
+	count := len(nodes)
+	values := make(map[string]any, len(d.values))
+	for k, v := range d.values {
+		values[k] = v
+	}
+	values["validators_count"] = count
+	reserved := append([]nodeInfo{}, d.nodes[:reservedNodes]...)
+	// Single node runs with the first validator wallet.
+	reserved[0].ValidatorHash = nodes[0].ValidatorHash
+	values["nodes_info"] = append(reserved, nodes...)
+
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("data.%d.yml", count))
+	return path, os.WriteFile(path, append([]byte("#@data/values\n---\n"), data...), 0o644)
+}
+
+// validators returns nodes info of the given number of validators. Known
+// entries keep their ports, the rest follow the same numbering. Standby
+// validator keys are read from wallet.<name>.json of the data directory,
+// missing wallets are generated with the node name as a password.
+func (d *templateData) validators(count int) ([]nodeInfo, error) {
+	res := make([]nodeInfo, count)
+	for i := range res {
+		if reservedNodes+i < len(d.nodes) {
+			res[i] = d.nodes[reservedNodes+i]
+		} else {
+			name := internal.NodeName(i)
+			res[i] = nodeInfo{
+				NodeName:           name,
+				NodePort:           20333 + i,
+				NodeRPCPort:        30333 + i,
+				NodeMonitoringPort: 20001 + i,
+				NodePprofPort:      30001 + i,
+				NodePrometheusPort: 40001 + i,
+				WalletPassword:     name,
+			}
+		}
+
+		pub, err := d.validatorKey(res[i].NodeName, res[i].WalletPassword)
+		if err != nil {
+			return nil, fmt.Errorf("validator %s: %w", res[i].NodeName, err)
+		}
+		res[i].ValidatorHash = pub.StringCompressed()
+	}
+	return res, nil
+}
+
+func (d *templateData) validatorKey(name, password string) (*keys.PublicKey, error) {
+	if pub, ok := d.keys[name]; ok {
+		return pub, nil
+	}
+
+	src := internal.KeySource{Wallet: walletFile(name), Password: password}
+	priv, err := src.Load(d.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		priv, err = createWallet(filepath.Join(d.dir, src.Wallet), password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.keys[name] = priv.PublicKey()
+	return d.keys[name], nil
+}
+
+// writeKeysConfig stores committee keys config the bench needs to prepare the
+// network of the given validators to the given directory.
+func writeKeysConfig(dir string, nodes []nodeInfo) error {
+	var cfg internal.KeysConfig
+	for _, n := range nodes {
+		cfg.Committee = append(cfg.Committee, internal.KeySource{Wallet: walletFile(n.NodeName), Password: n.WalletPassword})
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, internal.KeysConfigName(len(nodes))), data, 0o644)
+}
+
+func walletFile(name string) string {
+	return "wallet." + name + ".json"
+}
+
+func createWallet(path, password string) (*keys.PrivateKey, error) {
+	priv, err := keys.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := wallet.NewWallet(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create wallet: %w", err)
+	}
+	defer w.Close()
+
+	acc := wallet.NewAccountFromPrivateKey(priv)
+	acc.Label = password
+	if err := acc.Encrypt(password, w.Scrypt); err != nil {
+		return nil, fmt.Errorf("could not encrypt account: %w", err)
+	}
+	w.AddAccount(acc)
+	if err := w.Save(); err != nil {
+		return nil, fmt.Errorf("could not save wallet: %w", err)
+	}
+
+	log.Printf("Generated wallet %s for %s", path, acc.Address)
+	return priv, nil
+}
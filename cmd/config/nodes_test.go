@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/neo-bench/internal"
+	"gopkg.in/yaml.v3"
+)
+
+// templateDir is the configuration templates directory relative to the test.
+const templateDir = "../../.docker/ir/"
+
+// newTestTemplateData returns template data of the repository stored in a
+// temporary directory without wallets.
+func newTestTemplateData(t *testing.T) *templateData {
+	dir := t.TempDir()
+	data, err := os.ReadFile(templateDir + templateDataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, templateDataFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := loadTemplateData(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestTemplateDataValidators(t *testing.T) {
+	const count = 9
+	d := newTestTemplateData(t)
+
+	nodes, err := d.validators(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != count {
+		t.Fatalf("expected %d nodes, got %d", count, len(nodes))
+	}
+	for i, n := range nodes {
+		if n.NodeName != internal.NodeName(i) {
+			t.Errorf("node #%d: unexpected name %s", i, n.NodeName)
+		}
+		// Known and added entries follow the same numbering.
+		if n.NodePort != 20333+i || n.NodeRPCPort != 30333+i || n.NodePprofPort != 30001+i || n.NodePrometheusPort != 40001+i {
+			t.Errorf("node %s: unexpected ports %+v", n.NodeName, n)
+		}
+		if _, err := os.Stat(filepath.Join(d.dir, walletFile(n.NodeName))); err != nil {
+			t.Errorf("node %s: %v", n.NodeName, err)
+		}
+		for _, other := range nodes[:i] {
+			if other.ValidatorHash == n.ValidatorHash {
+				t.Errorf("nodes %s and %s share the key", other.NodeName, n.NodeName)
+			}
+		}
+	}
+	if nodes[7].WalletPassword != nodes[7].NodeName {
+		t.Errorf("unexpected password of the generated wallet %s", nodes[7].WalletPassword)
+	}
+
+	// Generated wallets are reused.
+	reloaded, err := loadTemplateData(d.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := reloaded.validators(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range nodes {
+		if again[i].ValidatorHash != nodes[i].ValidatorHash {
+			t.Fatalf("node %s: key is regenerated", nodes[i].NodeName)
+		}
+	}
+}
+
+func TestTemplateDataWrite(t *testing.T) {
+	d := newTestTemplateData(t)
+	nodes, err := d.validators(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := d.write(t.TempDir(), nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values struct {
+		ValidatorsCount int        `yaml:"validators_count"`
+		EnableHardforks bool       `yaml:"enable_hardforks"`
+		NodesInfo       []nodeInfo `yaml:"nodes_info"`
+	}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+	if values.ValidatorsCount != 5 || !values.EnableHardforks || len(values.NodesInfo) != reservedNodes+5 {
+		t.Fatalf("unexpected values %+v", values)
+	}
+	if single := values.NodesInfo[0]; single.NodeName != singleNodeName || single.ValidatorHash != nodes[0].ValidatorHash {
+		t.Fatalf("single node should use the first validator key: %+v", single)
+	}
+	if values.NodesInfo[reservedNodes+4] != nodes[4] {
+		t.Fatalf("unexpected validator %+v", values.NodesInfo[reservedNodes+4])
+	}
+}
+
+func TestWriteKeysConfig(t *testing.T) {
+	d := newTestTemplateData(t)
+	nodes, err := d.validators(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeKeysConfig(d.dir, nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := internal.LoadKeys(filepath.Join(d.dir, internal.KeysConfigName(5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.Committee) != len(nodes) {
+		t.Fatalf("expected %d committee keys, got %d", len(nodes), len(ks.Committee))
+	}
+	for i, priv := range ks.Committee {
+		if priv.PublicKey().StringCompressed() != nodes[i].ValidatorHash {
+			t.Fatalf("committee key #%d doesn't match validator %s", i, nodes[i].NodeName)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Node roles overrides are applied to.
+const (
+	roleValidator = "validator"
+	roleRPC       = "rpc"
+)
+
+type (
+	// Overrides patches generated node configurations, keys follow the
+	// structure of Go YAML and C# JSON configuration files respectively.
+	Overrides struct {
+		Go    NodeOverrides `yaml:"go"`
+		Sharp NodeOverrides `yaml:"sharp"`
+	}
+
+	// NodeOverrides are patches applied to all nodes, to nodes of the given
+	// role and to the given node in this order, so that the more specific
+	// patch wins. Maps are merged, other values are replaced.
+	NodeOverrides struct {
+		All       map[string]any            `yaml:"all"`
+		Validator map[string]any            `yaml:"validator"`
+		RPC       map[string]any            `yaml:"rpc"`
+		Nodes     map[string]map[string]any `yaml:"nodes"`
+	}
+)
+
+func loadOverrides(path string) (*Overrides, error) {
+	var o Overrides
+	if path == "" {
+		return &o, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read overrides: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&o); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not decode overrides %s: %w", path, err)
+	}
+	return &o, nil
+}
+
+// patches returns overrides of the node of the given role and name (empty for
+// RPC node) in the order they should be applied.
+func (o NodeOverrides) patches(role, name string) []map[string]any {
+	res := []map[string]any{o.All}
+	switch role {
+	case roleValidator:
+		res = append(res, o.Validator)
+	case roleRPC:
+		res = append(res, o.RPC)
+	}
+	if name != "" {
+		res = append(res, o.Nodes[name])
+	}
+	return res
+}
+
+// applyOverrides patches cfg with the given overrides. The result is decoded
+// back into the configuration structure, so that invalid values are reported
+// as well as unknown keys unless the structure keeps them in inline maps like
+// C# configuration does.
+func applyOverrides[T any](cfg *T, patches []map[string]any) error {
+	var changed bool
+	for _, p := range patches {
+		changed = changed || len(p) != 0
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for _, p := range patches {
+		mergeValues(m, p)
+	}
+	if data, err = yaml.Marshal(m); err != nil {
+		return err
+	}
+
+	var res T
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&res); err != nil {
+		return fmt.Errorf("invalid overrides: %w", err)
+	}
+	*cfg = res
+	return nil
+}
+
+// mergeValues merges src into dst recursively, src maps are copied so that
+// they're not modified by the following merges.
+func mergeValues(dst, src map[string]any) {
+	for k, v := range src {
+		sub, ok := v.(map[string]any)
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		cur, ok := dst[k].(map[string]any)
+		if !ok {
+			cur = make(map[string]any, len(sub))
+			dst[k] = cur
+		}
+		mergeValues(cur, sub)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeValues(t *testing.T) {
+	dst := map[string]any{
+		"A": 1,
+		"B": map[string]any{"X": 1, "Y": 2},
+		"C": "c",
+	}
+	src := map[string]any{
+		"A": 2,
+		"B": map[string]any{"Y": 3, "Z": 4},
+		"C": map[string]any{"X": 5},
+		"D": map[string]any{"X": 6},
+	}
+	mergeValues(dst, src)
+
+	want := map[string]any{
+		"A": 2,
+		"B": map[string]any{"X": 1, "Y": 3, "Z": 4},
+		"C": map[string]any{"X": 5},
+		"D": map[string]any{"X": 6},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("expected %v, got %v", want, dst)
+	}
+
+	// Following merges shouldn't modify the source.
+	mergeValues(dst, map[string]any{"D": map[string]any{"X": 7}})
+	if src["D"].(map[string]any)["X"] != 6 {
+		t.Fatal("source is modified")
+	}
+}
+
+func TestNodeOverridesPatches(t *testing.T) {
+	o := NodeOverrides{
+		All:       map[string]any{"A": 1},
+		Validator: map[string]any{"A": 2},
+		RPC:       map[string]any{"A": 3},
+		Nodes:     map[string]map[string]any{"one": {"A": 4}},
+	}
+	tests := []struct {
+		role, name string
+		want       []any
+	}{
+		{roleValidator, "one", []any{1, 2, 4}},
+		{roleValidator, "two", []any{1, 2, nil}},
+		{roleRPC, "", []any{1, 3}},
+	}
+	for _, tc := range tests {
+		var got []any
+		for _, p := range o.patches(tc.role, tc.name) {
+			got = append(got, p["A"])
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %s: expected %v, got %v", tc.role, tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	type section struct {
+		Size  int      `yaml:"Size"`
+		Peers []string `yaml:"Peers"`
+	}
+	type config struct {
+		Name    string  `yaml:"Name"`
+		Section section `yaml:"Section"`
+	}
+	base := config{Name: "node", Section: section{Size: 1, Peers: []string{"a"}}}
+
+	tests := []struct {
+		name    string
+		patches []map[string]any
+		want    config
+		ok      bool
+	}{
+		{"none", []map[string]any{nil, {}}, base, true},
+		{"merge", []map[string]any{
+			{"Section": map[string]any{"Size": 2}},
+			{"Section": map[string]any{"Peers": []any{"b", "c"}}},
+		}, config{Name: "node", Section: section{Size: 2, Peers: []string{"b", "c"}}}, true},
+		{"specific wins", []map[string]any{{"Name": "all"}, {"Name": "one"}},
+			config{Name: "one", Section: base.Section}, true},
+		{"unknown key", []map[string]any{{"Section": map[string]any{"Sise": 2}}}, base, false},
+		{"invalid value", []map[string]any{{"Section": map[string]any{"Size": "big"}}}, base, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base
+			err := applyOverrides(&cfg, tc.patches)
+			if (err == nil) != tc.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, cfg)
+			}
+		})
+	}
+}
+
+func TestApplyOverridesSharpExtra(t *testing.T) {
+	cfg := SharpTemplate{ProtocolConfiguration: ProtocolConfiguration{Network: 56753}}
+	err := applyOverrides(&cfg, []map[string]any{{
+		"ProtocolConfiguration": map[string]any{"MillisecondsPerBlock": 1000, "MaxBlockSize": 1024},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := cfg.ProtocolConfiguration
+	if p.Network != 56753 || p.MillisecondsPerBlock != 1000 || p.Extra["MaxBlockSize"] != 1024 {
+		t.Fatalf("unexpected configuration %+v", p)
+	}
+}
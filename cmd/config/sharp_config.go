@@ -1,55 +1,266 @@
 package main
 
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
+// SharpConfig is a C# node config.json. Extra fields of its sections and of
+// plugin configurations hold settings not modelled explicitly, they're passed
+// to the resulting JSON as is.
 type SharpConfig struct {
 	ApplicationConfiguration ApplicationConfiguration `yaml:"ApplicationConfiguration"`
 	ProtocolConfiguration    ProtocolConfiguration    `yaml:"ProtocolConfiguration"`
+	Extra                    map[string]any           `yaml:",inline" json:"-"`
 }
 
 type ApplicationConfiguration struct {
-	Logger       Logger       `yaml:"Logger"`
-	Storage      Storage      `yaml:"Storage"`
-	P2P          P2P          `yaml:"P2P"`
-	UnlockWallet UnlockWallet `yaml:"UnlockWallet"`
-	PluginURL    string       `yaml:"PluginURL"`
+	Logger       Logger         `yaml:"Logger"`
+	Storage      Storage        `yaml:"Storage"`
+	P2P          P2P            `yaml:"P2P"`
+	UnlockWallet UnlockWallet   `yaml:"UnlockWallet"`
+	Contracts    *Contracts     `yaml:"Contracts,omitempty" json:",omitempty"`
+	Plugins      *PluginsSource `yaml:"Plugins,omitempty" json:",omitempty"`
+	PluginURL    string         `yaml:"PluginURL"`
+	Extra        map[string]any `yaml:",inline" json:"-"`
 }
 
 type Logger struct {
-	Path          string `yaml:"Path"`
-	ConsoleOutput bool   `yaml:"ConsoleOutput"`
-	Active        bool   `yaml:"Active"`
+	Path          string         `yaml:"Path"`
+	ConsoleOutput bool           `yaml:"ConsoleOutput"`
+	Active        bool           `yaml:"Active"`
+	Extra         map[string]any `yaml:",inline" json:"-"`
 }
 
 type Storage struct {
-	Engine string `yaml:"Engine"`
-	Path   string `yaml:"Path"`
+	Engine string         `yaml:"Engine"`
+	Path   string         `yaml:"Path"`
+	Extra  map[string]any `yaml:",inline" json:"-"`
 }
 
 type P2P struct {
-	Port                     uint16 `yaml:"Port"`
-	WsPort                   uint16 `yaml:"WsPort"`
-	MaxConnections           int    `yaml:"MaxConnections"`
-	MaxConnectionsPerAddress int    `yaml:"MaxConnectionsPerAddress"`
+	Port                     uint16         `yaml:"Port"`
+	WsPort                   uint16         `yaml:"WsPort"`
+	EnableCompression        *bool          `yaml:"EnableCompression,omitempty" json:",omitempty"`
+	MinDesiredConnections    int            `yaml:"MinDesiredConnections,omitempty" json:",omitempty"`
+	MaxConnections           int            `yaml:"MaxConnections"`
+	MaxKnownHashes           int            `yaml:"MaxKnownHashes,omitempty" json:",omitempty"`
+	MaxConnectionsPerAddress int            `yaml:"MaxConnectionsPerAddress"`
+	Extra                    map[string]any `yaml:",inline" json:"-"`
 }
 
 type UnlockWallet struct {
-	Path     string `yaml:"Path"`
-	Password string `yaml:"Password"`
-	IsActive bool   `yaml:"IsActive"`
+	Path     string         `yaml:"Path"`
+	Password string         `yaml:"Password"`
+	IsActive bool           `yaml:"IsActive"`
+	Extra    map[string]any `yaml:",inline" json:"-"`
+}
+
+type Contracts struct {
+	NeoNameService string         `yaml:"NeoNameService,omitempty" json:",omitempty"`
+	Extra          map[string]any `yaml:",inline" json:"-"`
+}
+
+type PluginsSource struct {
+	DownloadUrl string         `yaml:"DownloadUrl,omitempty" json:",omitempty"`
+	Prerelease  bool           `yaml:"Prerelease,omitempty" json:",omitempty"`
+	Version     string         `yaml:"Version,omitempty" json:",omitempty"`
+	Extra       map[string]any `yaml:",inline" json:"-"`
 }
 
 type ProtocolConfiguration struct {
 	Network                     uint32         `yaml:"Network"`
+	AddressVersion              byte           `yaml:"AddressVersion,omitempty" json:",omitempty"`
 	MaxTransactionsPerBlock     int32          `yaml:"MaxTransactionsPerBlock"`
 	MillisecondsPerBlock        int            `yaml:"MillisecondsPerBlock"`
 	MaxValidUntilBlockIncrement int            `yaml:"MaxValidUntilBlockIncrement"`
+	MaxTraceableBlocks          uint32         `yaml:"MaxTraceableBlocks,omitempty" json:",omitempty"`
+	InitialGasDistribution      uint64         `yaml:"InitialGasDistribution,omitempty" json:",omitempty"`
 	ValidatorsCount             int            `yaml:"ValidatorsCount"`
 	MemoryPoolMaxTransactions   int            `yaml:"MemoryPoolMaxTransactions"`
 	StandbyCommittee            []string       `yaml:"StandbyCommittee"`
 	SeedList                    []string       `yaml:"SeedList"`
 	Hardforks                   map[string]int `yaml:"Hardforks,omitempty" json:"Hardforks,omitempty"`
+	Extra                       map[string]any `yaml:",inline" json:"-"`
+}
+
+// SharpPlugins are configurations of plugins stored in Plugins/<name>/<name>.json,
+// image defaults are used for the missing ones. Only RpcServer and DBFTPlugin
+// configurations are written and mounted, the other ones are kept in Extra to
+// be rejected by validation.
+type SharpPlugins struct {
+	RpcServer  *RpcServerConfig  `yaml:"RpcServer,omitempty"`
+	DBFTPlugin *DBFTPluginConfig `yaml:"DBFTPlugin,omitempty"`
+	Extra      map[string]any    `yaml:",inline"`
+}
+
+type RpcServerConfig struct {
+	PluginConfiguration RpcServerSettings `yaml:"PluginConfiguration"`
+	Extra               map[string]any    `yaml:",inline" json:"-"`
+}
+
+type RpcServerSettings struct {
+	UnhandledExceptionPolicy string         `yaml:"UnhandledExceptionPolicy"`
+	Servers                  []RpcServer    `yaml:"Servers"`
+	Extra                    map[string]any `yaml:",inline" json:"-"`
+}
+
+type RpcServer struct {
+	Network                  uint32         `yaml:"Network"`
+	BindAddress              string         `yaml:"BindAddress"`
+	Port                     uint16         `yaml:"Port"`
+	SslCert                  string         `yaml:"SslCert"`
+	SslCertPassword          string         `yaml:"SslCertPassword"`
+	TrustedAuthorities       []string       `yaml:"TrustedAuthorities"`
+	RpcUser                  string         `yaml:"RpcUser"`
+	RpcPass                  string         `yaml:"RpcPass"`
+	MaxGasInvoke             float64        `yaml:"MaxGasInvoke"`
+	MaxFee                   float64        `yaml:"MaxFee"`
+	MaxConcurrentConnections int            `yaml:"MaxConcurrentConnections"`
+	MaxIteratorResultItems   int            `yaml:"MaxIteratorResultItems,omitempty" json:",omitempty"`
+	MaxStackSize             int            `yaml:"MaxStackSize,omitempty" json:",omitempty"`
+	MaxRequestBodySize       int            `yaml:"MaxRequestBodySize,omitempty" json:",omitempty"`
+	FindStoragePageSize      int            `yaml:"FindStoragePageSize,omitempty" json:",omitempty"`
+	SessionEnabled           bool           `yaml:"SessionEnabled,omitempty" json:",omitempty"`
+	SessionExpirationTime    int            `yaml:"SessionExpirationTime,omitempty" json:",omitempty"`
+	DisabledMethods          []string       `yaml:"DisabledMethods"`
+	EnableCors               bool           `yaml:"EnableCors"`
+	AllowOrigins             []string       `yaml:"AllowOrigins"`
+	KeepAliveTimeout         int            `yaml:"KeepAliveTimeout"`
+	RequestHeadersTimeout    int            `yaml:"RequestHeadersTimeout"`
+	Extra                    map[string]any `yaml:",inline" json:"-"`
 }
 
+type DBFTPluginConfig struct {
+	PluginConfiguration DBFTPluginSettings `yaml:"PluginConfiguration"`
+	Extra               map[string]any     `yaml:",inline" json:"-"`
+}
+
+type DBFTPluginSettings struct {
+	RecoveryLogs             string         `yaml:"RecoveryLogs"`
+	IgnoreRecoveryLogs       bool           `yaml:"IgnoreRecoveryLogs"`
+	AutoStart                bool           `yaml:"AutoStart"`
+	Network                  uint32         `yaml:"Network"`
+	MaxBlockSize             uint32         `yaml:"MaxBlockSize"`
+	MaxBlockSystemFee        int64          `yaml:"MaxBlockSystemFee"`
+	UnhandledExceptionPolicy string         `yaml:"UnhandledExceptionPolicy"`
+	Extra                    map[string]any `yaml:",inline" json:"-"`
+}
+
+// SharpTemplate is a C# node template, its Extra sections are passed to the
+// node configuration.
 type SharpTemplate struct {
 	ApplicationConfiguration ApplicationConfiguration `yaml:"ApplicationConfiguration"`
 	ProtocolConfiguration    ProtocolConfiguration    `yaml:"ProtocolConfiguration"`
+	Plugins                  SharpPlugins             `yaml:"Plugins"`
+	Extra                    map[string]any           `yaml:",inline"`
+}
+
+func (c SharpConfig) MarshalJSON() ([]byte, error) {
+	type plain SharpConfig
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c ApplicationConfiguration) MarshalJSON() ([]byte, error) {
+	type plain ApplicationConfiguration
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c Logger) MarshalJSON() ([]byte, error) {
+	type plain Logger
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c Storage) MarshalJSON() ([]byte, error) {
+	type plain Storage
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c P2P) MarshalJSON() ([]byte, error) {
+	type plain P2P
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c UnlockWallet) MarshalJSON() ([]byte, error) {
+	type plain UnlockWallet
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c Contracts) MarshalJSON() ([]byte, error) {
+	type plain Contracts
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c PluginsSource) MarshalJSON() ([]byte, error) {
+	type plain PluginsSource
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c ProtocolConfiguration) MarshalJSON() ([]byte, error) {
+	type plain ProtocolConfiguration
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c RpcServerConfig) MarshalJSON() ([]byte, error) {
+	type plain RpcServerConfig
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c RpcServerSettings) MarshalJSON() ([]byte, error) {
+	type plain RpcServerSettings
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c RpcServer) MarshalJSON() ([]byte, error) {
+	type plain RpcServer
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c DBFTPluginConfig) MarshalJSON() ([]byte, error) {
+	type plain DBFTPluginConfig
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+func (c DBFTPluginSettings) MarshalJSON() ([]byte, error) {
+	type plain DBFTPluginSettings
+	return marshalJSONWithExtra(plain(c), c.Extra)
+}
+
+// marshalJSONWithExtra marshals v as JSON object and appends extra fields not
+// present in it, so that the order of known fields is kept.
+func marshalJSONWithExtra(v any, extra map[string]any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for _, k := range slices.Sorted(maps.Keys(extra)) {
+		if _, ok := known[k]; ok {
+			continue
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(extra[k])
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testSharpTemplate = `
+ApplicationConfiguration:
+  Logger:
+    Path: Logs
+    Active: true
+    Level: Debug
+  Storage:
+    Engine: LevelDBStore
+    Path: Data_LevelDB_{0}
+    Options:
+      Compression: true
+  P2P:
+    Port: 20333
+  UnlockWallet:
+    Path: wallet.json
+    IsActive: true
+    Timeout: 10
+  Contracts:
+    NeoNameService: "0x50ac1c37690cc2cfc594472833cf57505d5f46de"
+    Oracle: "0x00"
+  Plugins:
+    DownloadUrl: https://example.com/plugins
+    Mirror: https://mirror.example.com
+ProtocolConfiguration:
+  Network: 56753
+Plugins:
+  RpcServer:
+    PluginConfiguration:
+      Servers: []
+    Dependency: []
+  DBFTPlugin:
+    PluginConfiguration:
+      AutoStart: true
+    Dependency: []
+  StateService:
+    PluginConfiguration:
+      FullState: true
+Extensions:
+  Enabled: true
+`
+
+func TestSharpTemplateExtra(t *testing.T) {
+	var tmpl SharpTemplate
+	if err := yaml.Unmarshal([]byte(testSharpTemplate), &tmpl); err != nil {
+		t.Fatal(err)
+	}
+	cfg := SharpConfig{
+		ApplicationConfiguration: tmpl.ApplicationConfiguration,
+		ProtocolConfiguration:    tmpl.ProtocolConfiguration,
+		Extra:                    tmpl.Extra,
+	}
+
+	tests := []struct {
+		name string
+		v    any
+		path []string
+		want any
+	}{
+		{"section", cfg, []string{"Extensions", "Enabled"}, true},
+		{"logger", cfg, []string{"ApplicationConfiguration", "Logger", "Level"}, "Debug"},
+		{"storage", cfg, []string{"ApplicationConfiguration", "Storage", "Options", "Compression"}, true},
+		{"wallet", cfg, []string{"ApplicationConfiguration", "UnlockWallet", "Timeout"}, float64(10)},
+		{"known", cfg, []string{"ApplicationConfiguration", "UnlockWallet", "Path"}, "wallet.json"},
+		{"contracts", cfg, []string{"ApplicationConfiguration", "Contracts", "Oracle"}, "0x00"},
+		{"plugins source", cfg, []string{"ApplicationConfiguration", "Plugins", "Mirror"}, "https://mirror.example.com"},
+		{"rpc", tmpl.Plugins.RpcServer, []string{"Dependency"}, []any{}},
+		{"dbft", tmpl.Plugins.DBFTPlugin, []string{"Dependency"}, []any{}},
+	}
+	for _, tc := range tests {
+		data, err := json.Marshal(tc.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got any
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range tc.path {
+			got = got.(map[string]any)[k]
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	if _, ok := tmpl.Plugins.Extra["StateService"]; !ok {
+		t.Fatal("unknown plugin configuration is lost")
+	}
+}
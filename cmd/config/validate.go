@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nspcc-dev/neo-go/pkg/config"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+)
+
+// sharpHardforkPrefix is a prefix of C# node hardfork names.
+const sharpHardforkPrefix = "HF_"
+
+type (
+	// nodeConfig is a generated configuration of either Go or C# node.
+	nodeConfig struct {
+		path string
+		// name is empty for RPC node.
+		name     string
+		role     string
+		goCfg    *config.Config
+		sharpCfg *SharpConfig
+		// sharpPlugins are C# node plugins configurations.
+		sharpPlugins *SharpPlugins
+	}
+
+	// protocolSummary holds protocol settings which must be the same for all
+	// nodes of the network regardless of implementation.
+	protocolSummary struct {
+		Network         uint32
+		ValidatorsCount int
+		Committee       []string
+		SeedList        []string
+		MsPerBlock      int64
+		MaxVUBIncrement uint32
+		// MaxBlockSize and MaxBlockSystemFee are zero if unknown (C# node
+		// without DBFTPlugin configuration).
+		MaxBlockSize      uint32
+		MaxBlockSystemFee int64
+		// Hardforks are keyed by neo-go names.
+		Hardforks map[string]uint32
+	}
+)
+
+// validateConfigs checks configurations of a single network generation run:
+// every node configuration is checked on its own and against the other nodes
+// of the same network, the single node is a network of its own. All problems
+// found are returned.
+func validateConfigs(nodes []nodeConfig) error {
+	var (
+		errs     []error
+		networks = make(map[bool][]nodeConfig)
+	)
+	for _, n := range nodes {
+		errs = append(errs, n.validate()...)
+		single := n.name == singleNodeName
+		networks[single] = append(networks[single], n)
+	}
+	for _, single := range []bool{false, true} {
+		errs = append(errs, validateNetwork(networks[single])...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateNetwork compares protocol settings of the network nodes with the
+// first one.
+func validateNetwork(nodes []nodeConfig) []error {
+	if len(nodes) < 2 {
+		return nil
+	}
+
+	var (
+		errs []error
+		ref  = nodes[0].protocol()
+	)
+	for _, n := range nodes[1:] {
+		p := n.protocol()
+		mismatch := func(setting string, v, refV any) {
+			errs = append(errs, fmt.Errorf("%s: %s %v differs from %v of %s", n, setting, v, refV, nodes[0]))
+		}
+		if p.Network != ref.Network {
+			mismatch("network magic", p.Network, ref.Network)
+		}
+		if p.ValidatorsCount != ref.ValidatorsCount {
+			mismatch("validators count", p.ValidatorsCount, ref.ValidatorsCount)
+		}
+		if !slices.Equal(p.Committee, ref.Committee) {
+			mismatch("standby committee", p.Committee, ref.Committee)
+		}
+		if !slices.Equal(slices.Sorted(slices.Values(p.SeedList)), slices.Sorted(slices.Values(ref.SeedList))) {
+			mismatch("seed list", p.SeedList, ref.SeedList)
+		}
+		if p.MsPerBlock != ref.MsPerBlock {
+			mismatch("milliseconds per block", p.MsPerBlock, ref.MsPerBlock)
+		}
+		if p.MaxVUBIncrement != ref.MaxVUBIncrement {
+			mismatch("MaxValidUntilBlockIncrement", p.MaxVUBIncrement, ref.MaxVUBIncrement)
+		}
+		if p.MaxBlockSize != 0 && ref.MaxBlockSize != 0 && p.MaxBlockSize != ref.MaxBlockSize {
+			mismatch("MaxBlockSize", p.MaxBlockSize, ref.MaxBlockSize)
+		}
+		if p.MaxBlockSystemFee != 0 && ref.MaxBlockSystemFee != 0 && p.MaxBlockSystemFee != ref.MaxBlockSystemFee {
+			mismatch("MaxBlockSystemFee", p.MaxBlockSystemFee, ref.MaxBlockSystemFee)
+		}
+		if !maps.Equal(p.Hardforks, ref.Hardforks) {
+			mismatch("hardforks", p.Hardforks, ref.Hardforks)
+		}
+	}
+	return errs
+}
+
+// validate checks consistency of the node configuration.
+func (n nodeConfig) validate() []error {
+	var (
+		errs []error
+		p    = n.protocol()
+		fail = func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("%s: %s", n, fmt.Sprintf(format, args...)))
+		}
+	)
+
+	if p.ValidatorsCount < 1 || p.ValidatorsCount > len(p.Committee) {
+		fail("ValidatorsCount %d doesn't match StandbyCommittee of %d keys", p.ValidatorsCount, len(p.Committee))
+	}
+	seen := make(map[string]bool)
+	for _, k := range p.Committee {
+		if _, err := keys.NewPublicKeyFromString(k); err != nil {
+			fail("invalid standby committee key %s: %v", k, err)
+		}
+		if seen[k] {
+			fail("duplicate standby committee key %s", k)
+		}
+		seen[k] = true
+	}
+	if len(p.SeedList) < p.ValidatorsCount {
+		fail("SeedList of %d nodes doesn't cover %d validators", len(p.SeedList), p.ValidatorsCount)
+	}
+	for name := range p.Hardforks {
+		if !config.IsHardforkValid(name) {
+			fail("unknown hardfork %s", name)
+		}
+	}
+
+	switch consensus := n.consensus(); {
+	case n.role == roleValidator && !consensus:
+		fail("consensus is disabled on validator")
+	case n.role == roleRPC && consensus:
+		fail("consensus is enabled on RPC node")
+	}
+
+	ports, err := n.ports()
+	if err != nil {
+		fail("%v", err)
+	}
+	used := make(map[uint16]string)
+	for _, service := range slices.Sorted(maps.Keys(ports)) {
+		port := ports[service]
+		if other, ok := used[port]; ok {
+			fail("%s and %s use the same port %d", other, service, port)
+		}
+		used[port] = service
+	}
+
+	if n.sharpPlugins != nil {
+		if rpc := n.sharpPlugins.RpcServer; rpc != nil {
+			for i, srv := range rpc.PluginConfiguration.Servers {
+				if srv.Network != p.Network {
+					fail("RpcServer #%d network %d differs from %d", i, srv.Network, p.Network)
+				}
+			}
+		}
+		if dbft := n.sharpPlugins.DBFTPlugin; dbft != nil && dbft.PluginConfiguration.Network != p.Network {
+			fail("DBFTPlugin network %d differs from %d", dbft.PluginConfiguration.Network, p.Network)
+		}
+		if len(n.sharpPlugins.Extra) != 0 {
+			fail("configurations of plugins %s can't be written, only RpcServer and DBFTPlugin are supported",
+				strings.Join(slices.Sorted(maps.Keys(n.sharpPlugins.Extra)), ", "))
+		}
+	}
+
+	if n.goCfg != nil {
+		if err := n.goCfg.ProtocolConfiguration.Validate(); err != nil {
+			fail("%v", err)
+		}
+		// Validation may fill some defaults in, so a copy is checked.
+		app := n.goCfg.ApplicationConfiguration
+		if err := app.Validate(); err != nil {
+			fail("%v", err)
+		}
+	}
+	return errs
+}
+
+// protocol returns implementation-independent protocol settings.
+func (n nodeConfig) protocol() protocolSummary {
+	if n.goCfg != nil {
+		p := n.goCfg.ProtocolConfiguration
+		return protocolSummary{
+			Network:           uint32(p.Magic),
+			ValidatorsCount:   int(p.ValidatorsCount),
+			Committee:         p.StandbyCommittee,
+			SeedList:          p.SeedList,
+			MsPerBlock:        p.TimePerBlock.Milliseconds(),
+			MaxVUBIncrement:   p.MaxValidUntilBlockIncrement,
+			MaxBlockSize:      p.MaxBlockSize,
+			MaxBlockSystemFee: p.MaxBlockSystemFee,
+			Hardforks:         p.Hardforks,
+		}
+	}
+
+	p := n.sharpCfg.ProtocolConfiguration
+	res := protocolSummary{
+		Network:         p.Network,
+		ValidatorsCount: p.ValidatorsCount,
+		Committee:       p.StandbyCommittee,
+		SeedList:        p.SeedList,
+		MsPerBlock:      int64(p.MillisecondsPerBlock),
+		MaxVUBIncrement: uint32(p.MaxValidUntilBlockIncrement),
+		Hardforks:       make(map[string]uint32, len(p.Hardforks)),
+	}
+	for name, height := range p.Hardforks {
+		res.Hardforks[strings.TrimPrefix(name, sharpHardforkPrefix)] = uint32(height)
+	}
+	if dbft := n.sharpPlugins.DBFTPlugin; dbft != nil {
+		res.MaxBlockSize = dbft.PluginConfiguration.MaxBlockSize
+		res.MaxBlockSystemFee = dbft.PluginConfiguration.MaxBlockSystemFee
+	}
+	return res
+}
+
+// consensus checks whether consensus service is enabled on the node.
+func (n nodeConfig) consensus() bool {
+	if n.goCfg != nil {
+		c := n.goCfg.ApplicationConfiguration.Consensus
+		return c.Enabled && c.UnlockWallet.Path != ""
+	}
+	w := n.sharpCfg.ApplicationConfiguration.UnlockWallet
+	return w.IsActive && w.Path != ""
+}
+
+// ports returns ports the node listens on keyed by service names.
+func (n nodeConfig) ports() (map[string]uint16, error) {
+	res := make(map[string]uint16)
+	if n.sharpCfg != nil {
+		p2p := n.sharpCfg.ApplicationConfiguration.P2P
+		res["P2P"] = p2p.Port
+		res["WebSocket"] = p2p.WsPort
+		if rpc := n.sharpPlugins.RpcServer; rpc != nil {
+			for i, srv := range rpc.PluginConfiguration.Servers {
+				res[fmt.Sprintf("RpcServer #%d", i)] = srv.Port
+			}
+		}
+		return res, nil
+	}
+
+	var (
+		errs []error
+		app  = n.goCfg.ApplicationConfiguration
+		add  = func(service string, addrs []string) {
+			for i, addr := range addrs {
+				_, port, err := net.SplitHostPort(addr)
+				if err == nil {
+					var p uint64
+					p, err = strconv.ParseUint(port, 10, 16)
+					res[fmt.Sprintf("%s address #%d", service, i)] = uint16(p)
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid %s address %s: %w", service, addr, err))
+				}
+			}
+		}
+	)
+	p2p, err := app.GetAddresses()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, a := range p2p {
+		add("P2P", []string{a.Address})
+	}
+	if app.RPC.Enabled {
+		add("RPC", app.RPC.Addresses)
+	}
+	if app.Prometheus.Enabled {
+		add("Prometheus", app.Prometheus.Addresses)
+	}
+	if app.Pprof.Enabled {
+		add("pprof", app.Pprof.Addresses)
+	}
+	return res, errors.Join(errs...)
+}
+
+// String returns the file name of the configuration.
+func (n nodeConfig) String() string {
+	return filepath.Base(n.path)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+)
+
+// testSharpNetwork returns C# configurations of four validators and RPC node.
+func testSharpNetwork(t *testing.T) []nodeConfig {
+	var committee []string
+	for range 4 {
+		p, err := keys.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		committee = append(committee, p.PublicKey().StringCompressed())
+	}
+	seeds := []string{"node_one:20333", "node_two:20334", "node_three:20335", "node_four:20336"}
+
+	var nodes []nodeConfig
+	for i, name := range []string{"one", "two", "three", "four", ""} {
+		n := nodeConfig{
+			path: "sharp.config." + name + ".json",
+			name: name,
+			role: roleValidator,
+			sharpCfg: &SharpConfig{
+				ApplicationConfiguration: ApplicationConfiguration{
+					P2P:          P2P{Port: uint16(20333 + i), WsPort: uint16(20343 + i)},
+					UnlockWallet: UnlockWallet{Path: "wallet.json", IsActive: true},
+				},
+				ProtocolConfiguration: ProtocolConfiguration{
+					Network:                     56753,
+					MillisecondsPerBlock:        1000,
+					MaxValidUntilBlockIncrement: 5760,
+					ValidatorsCount:             4,
+					StandbyCommittee:            committee,
+					SeedList:                    seeds,
+					Hardforks:                   map[string]int{"HF_Aspidochelone": 0},
+				},
+			},
+			sharpPlugins: &SharpPlugins{
+				RpcServer: &RpcServerConfig{PluginConfiguration: RpcServerSettings{
+					Servers: []RpcServer{{Network: 56753, Port: uint16(30333 + i)}},
+				}},
+				DBFTPlugin: &DBFTPluginConfig{PluginConfiguration: DBFTPluginSettings{
+					Network:      56753,
+					MaxBlockSize: 262144,
+				}},
+			},
+		}
+		if name == "" {
+			n.path = "sharp.config.json"
+			n.role = roleRPC
+			n.sharpCfg.ApplicationConfiguration.UnlockWallet = UnlockWallet{}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+func TestValidateConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(nodes []nodeConfig)
+		errs   []string
+	}{
+		{"valid", func([]nodeConfig) {}, nil},
+		{"network mismatch", func(nodes []nodeConfig) {
+			nodes[2].sharpCfg.ProtocolConfiguration.Network = 1
+			nodes[2].sharpPlugins.RpcServer.PluginConfiguration.Servers[0].Network = 1
+			nodes[2].sharpPlugins.DBFTPlugin.PluginConfiguration.Network = 1
+		}, []string{"sharp.config.three.json: network magic 1 differs from 56753 of sharp.config.one.json"}},
+		{"plugin network", func(nodes []nodeConfig) {
+			nodes[1].sharpPlugins.DBFTPlugin.PluginConfiguration.Network = 1
+		}, []string{"sharp.config.two.json: DBFTPlugin network 1 differs from 56753"}},
+		{"block time", func(nodes []nodeConfig) {
+			nodes[4].sharpCfg.ProtocolConfiguration.MillisecondsPerBlock = 15000
+		}, []string{"sharp.config.json: milliseconds per block 15000 differs"}},
+		{"block size", func(nodes []nodeConfig) {
+			nodes[3].sharpPlugins.DBFTPlugin.PluginConfiguration.MaxBlockSize = 1
+		}, []string{"sharp.config.four.json: MaxBlockSize 1 differs"}},
+		{"unknown block size", func(nodes []nodeConfig) {
+			nodes[3].sharpPlugins.DBFTPlugin = nil
+		}, nil},
+		{"hardforks", func(nodes []nodeConfig) {
+			nodes[1].sharpCfg.ProtocolConfiguration.Hardforks = map[string]int{"HF_Aspidochelone": 10}
+		}, []string{"sharp.config.two.json: hardforks"}},
+		{"unknown hardfork", func(nodes []nodeConfig) {
+			for _, n := range nodes {
+				n.sharpCfg.ProtocolConfiguration.Hardforks = map[string]int{"HF_Unknown": 0}
+			}
+		}, []string{"sharp.config.one.json: unknown hardfork Unknown"}},
+		{"validators count", func(nodes []nodeConfig) {
+			for _, n := range nodes {
+				n.sharpCfg.ProtocolConfiguration.ValidatorsCount = 5
+			}
+		}, []string{"ValidatorsCount 5 doesn't match StandbyCommittee of 4 keys"}},
+		{"consensus on RPC", func(nodes []nodeConfig) {
+			nodes[4].sharpCfg.ApplicationConfiguration.UnlockWallet = UnlockWallet{Path: "wallet.json", IsActive: true}
+		}, []string{"sharp.config.json: consensus is enabled on RPC node"}},
+		{"consensus off", func(nodes []nodeConfig) {
+			nodes[0].sharpCfg.ApplicationConfiguration.UnlockWallet.IsActive = false
+		}, []string{"sharp.config.one.json: consensus is disabled on validator"}},
+		{"other plugin", func(nodes []nodeConfig) {
+			nodes[2].sharpPlugins.Extra = map[string]any{"StateService": map[string]any{}, "ApplicationLogs": nil}
+		}, []string{"sharp.config.three.json: configurations of plugins ApplicationLogs, StateService can't be written"}},
+		{"ports", func(nodes []nodeConfig) {
+			nodes[0].sharpCfg.ApplicationConfiguration.P2P.WsPort = 20333
+		}, []string{"sharp.config.one.json: P2P and WebSocket use the same port 20333"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes := testSharpNetwork(t)
+			tc.modify(nodes)
+
+			err := validateConfigs(nodes)
+			if len(tc.errs) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, e := range tc.errs {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("expected %q in:\n%v", e, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigsSingle(t *testing.T) {
+	nodes := testSharpNetwork(t)
+	single := nodes[0]
+	single.name = singleNodeName
+	single.path = "sharp.config.single.json"
+	single.sharpCfg = &SharpConfig{
+		ApplicationConfiguration: single.sharpCfg.ApplicationConfiguration,
+		ProtocolConfiguration:    single.sharpCfg.ProtocolConfiguration,
+	}
+	// The single node is a network of its own.
+	single.sharpCfg.ProtocolConfiguration.MillisecondsPerBlock = 5000
+	single.sharpCfg.ProtocolConfiguration.ValidatorsCount = 1
+	single.sharpCfg.ProtocolConfiguration.SeedList = single.sharpCfg.ProtocolConfiguration.SeedList[:1]
+
+	if err := validateConfigs(append(nodes, single)); err != nil {
+		t.Fatal(err)
+	}
+}
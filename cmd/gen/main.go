@@ -2,10 +2,10 @@ package main
 
 import (
 	"flag"
+	"log"
 	"os"
 
 	"github.com/nspcc-dev/neo-bench/internal"
-	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
 )
 
 var (
@@ -16,6 +16,16 @@ var (
 
 	fromCount = flag.Int("from", 1, "Amount of tx senders")
 	toCount   = flag.Int("to", 1, "Amount of tx recipients")
+
+	signersCount = flag.Int("signers", 1, "Amount of tx signers (sender and cosigners taken from other senders)")
+	scope        = flag.String("scope", internal.ScopeCalledByEntry, "Witness scope of tx signers (entry, contracts, groups, rules or global)")
+
+	fromDist = flag.String("from-dist", internal.DistRoundRobin, "Distribution of tx senders (roundrobin, uniform or zipf)")
+	toDist   = flag.String("to-dist", internal.DistRoundRobin, "Distribution of tx recipients (roundrobin, uniform, zipf or fresh)")
+
+	seed = flag.Uint64("seed", 0, "Seed to derive senders, recipients and nonces from, random if not set")
+
+	keysConfig = flag.String("keys", "", "Path to keys config with the first sender key, default key is used if not set")
 )
 
 func main() {
@@ -27,21 +37,31 @@ func main() {
 	case inp != nil && *inp != "":
 		internal.ReadDump(*inp)
 	case out != nil && *out != "" && cnt != nil && *cnt > 0:
-		var err error
-		senders := make([]*keys.PrivateKey, *fromCount)
-		senders[0], _ = keys.NewPrivateKeyFromWIF("KxhEDBQyyEFymvfJD96q8stMbJMbZUb6D1PmXqBWZDU2WvbvVs9o")
-		for i := 1; i < len(senders); i++ {
-			senders[i], err = keys.NewPrivateKey()
-			if err != nil {
-				panic(err)
-			}
+		if *seed == 0 {
+			*seed = internal.NewSeed()
+		}
+
+		ks, err := internal.LoadKeys(*keysConfig)
+		if err != nil {
+			log.Fatalf("Could not load keys: %v", err)
 		}
-		internal.WriteDump(ctx, *out, internal.BenchOptions{
+
+		opts := internal.BenchOptions{
 			TransferType: *typ,
 			TxCount:      uint64(*cnt),
 			ToCount:      *toCount,
-			Senders:      senders,
-		})
+			Senders:      internal.NewSenders(ks.Sender, *fromCount, *seed),
+			SignersCount: *signersCount,
+			WitnessScope: *scope,
+
+			SendersDistribution:   *fromDist,
+			ReceiversDistribution: *toDist,
+			Seed:                  *seed,
+		}
+		if err := opts.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		internal.WriteDump(ctx, *out, opts)
 	default:
 		flag.PrintDefaults()
 		os.Exit(0)
@@ -0,0 +1,279 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/moby/moby/client"
+)
+
+type (
+	// ChaosAction is a Docker operation on a node container performed at the
+	// given offset from the benchmark start.
+	ChaosAction struct {
+		At     time.Duration
+		Action string
+		// Container is a container name or ID.
+		Container string
+	}
+
+	chaosParams struct {
+		enableLogger bool
+		actions      []ChaosAction
+	}
+
+	// ChaosOption is an option type to configure chaos actions.
+	ChaosOption func(*chaosParams)
+
+	// ChaosCallback is used to report performed action and its error if any.
+	ChaosCallback func(a ChaosAction, err error)
+
+	// Chaos performs actions on node containers via Docker API.
+	Chaos struct {
+		*log.Logger
+
+		cli     *client.Client
+		actions []ChaosAction
+		// networks are endpoints of disconnected containers keyed by network
+		// name, they're restored on connect, so that aliases are kept.
+		networks map[string]map[string]*network.EndpointSettings
+	}
+)
+
+// Chaos actions.
+const (
+	ChaosPause      = "pause"
+	ChaosUnpause    = "unpause"
+	ChaosStop       = "stop"
+	ChaosKill       = "kill"
+	ChaosStart      = "start"
+	ChaosRestart    = "restart"
+	ChaosDisconnect = "disconnect"
+	ChaosConnect    = "connect"
+)
+
+var chaosActions = []string{ChaosPause, ChaosUnpause, ChaosStop, ChaosKill,
+	ChaosStart, ChaosRestart, ChaosDisconnect, ChaosConnect}
+
+// ParseChaosAction parses action in <at>:<action>:<container> format, e.g.
+// "1m:pause:neo_go_node_four".
+func ParseChaosAction(s string) (ChaosAction, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return ChaosAction{}, fmt.Errorf("chaos action should be in <at>:<action>:<container> format, got %s", s)
+	}
+
+	at, err := time.ParseDuration(parts[0])
+	if err != nil || at < 0 {
+		return ChaosAction{}, fmt.Errorf("invalid chaos action time %s", parts[0])
+	}
+	if !slices.Contains(chaosActions, parts[1]) {
+		return ChaosAction{}, fmt.Errorf("unknown chaos action %s, possible values: %s", parts[1], strings.Join(chaosActions, ", "))
+	}
+	return ChaosAction{At: at, Action: parts[1], Container: parts[2]}, nil
+}
+
+// String returns action in the format accepted by ParseChaosAction.
+func (a ChaosAction) String() string {
+	return fmt.Sprintf("%s:%s:%s", a.At, a.Action, a.Container)
+}
+
+// ChaosEnableLogger enables logs.
+func ChaosEnableLogger() ChaosOption {
+	return func(p *chaosParams) {
+		p.enableLogger = true
+	}
+}
+
+// ChaosActions sets actions to perform.
+func ChaosActions(actions []ChaosAction) ChaosOption {
+	return func(p *chaosParams) {
+		p.actions = actions
+	}
+}
+
+// NewChaos creates Chaos, containers of all actions should exist.
+func NewChaos(ctx context.Context, opts ...ChaosOption) (*Chaos, error) {
+	p := &chaosParams{}
+	for i := range opts {
+		opts[i](p)
+	}
+
+	cli, err := client.NewClientWithOpts(client.WithVersion("1.40"))
+	if err != nil {
+		return nil, fmt.Errorf("docker client init: %w", err)
+	}
+
+	actions := slices.Clone(p.actions)
+	slices.SortStableFunc(actions, func(a, b ChaosAction) int { return cmp.Compare(a.At, b.At) })
+
+	var errs []error
+	for _, a := range actions {
+		if _, err := cli.ContainerInspect(ctx, a.Container); err != nil {
+			errs = append(errs, fmt.Errorf("chaos action %s: %w", a, err))
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	c := &Chaos{
+		cli:      cli,
+		actions:  actions,
+		networks: make(map[string]map[string]*network.EndpointSettings),
+		Logger:   log.New(os.Stdout, "", log.LstdFlags),
+	}
+	if !p.enableLogger {
+		c.SetOutput(io.Discard)
+	}
+	return c, nil
+}
+
+// Run performs actions at their offsets from start until the context is done,
+// every action is passed to cb after it's performed. Once the context is done,
+// containers left paused, stopped or disconnected by the actions are restored
+// and Run returns.
+func (c *Chaos) Run(ctx context.Context, start time.Time, cb ChaosCallback) {
+	defer c.restore(ctx, start, cb)
+
+	for _, a := range c.actions {
+		timer := time.NewTimer(time.Until(start.Add(a.At)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		c.perform(ctx, a, cb)
+	}
+	<-ctx.Done()
+}
+
+// Close releases Docker client.
+func (c *Chaos) Close() error {
+	return c.cli.Close()
+}
+
+func (c *Chaos) perform(ctx context.Context, a ChaosAction, cb ChaosCallback) {
+	err := c.do(ctx, a)
+	if err != nil {
+		c.Printf("Chaos action %s failed: %v", a, err)
+	} else {
+		c.Printf("Chaos action %s done", a)
+	}
+	cb(a, err)
+}
+
+// restore unpauses, starts and connects back containers of the actions, so
+// that they're not affected after the run. Restoring actions are passed to
+// cb as well.
+func (c *Chaos) restore(ctx context.Context, start time.Time, cb ChaosCallback) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultTimeout)
+	defer cancel()
+
+	seen := make(map[string]bool)
+	for _, a := range c.actions {
+		if seen[a.Container] {
+			continue
+		}
+		seen[a.Container] = true
+
+		info, err := c.cli.ContainerInspect(ctx, a.Container)
+		if err != nil {
+			c.Printf("Could not restore %s: %v", a.Container, err)
+			continue
+		}
+		var actions []string
+		switch {
+		case info.State.Paused:
+			actions = append(actions, ChaosUnpause)
+		case !info.State.Running:
+			actions = append(actions, ChaosStart)
+		}
+		if len(c.networks[a.Container]) != 0 {
+			actions = append(actions, ChaosConnect)
+		}
+		for _, action := range actions {
+			c.perform(ctx, ChaosAction{At: time.Since(start), Action: action, Container: a.Container}, cb)
+		}
+	}
+}
+
+func (c *Chaos) do(ctx context.Context, a ChaosAction) error {
+	switch a.Action {
+	case ChaosPause:
+		return c.cli.ContainerPause(ctx, a.Container)
+	case ChaosUnpause:
+		return c.cli.ContainerUnpause(ctx, a.Container)
+	case ChaosStop:
+		return c.cli.ContainerStop(ctx, a.Container, container.StopOptions{})
+	case ChaosKill:
+		return c.cli.ContainerKill(ctx, a.Container, "SIGKILL")
+	case ChaosStart:
+		return c.cli.ContainerStart(ctx, a.Container, container.StartOptions{})
+	case ChaosRestart:
+		return c.cli.ContainerRestart(ctx, a.Container, container.StopOptions{})
+	case ChaosDisconnect:
+		return c.disconnect(ctx, a.Container)
+	case ChaosConnect:
+		return c.connect(ctx, a.Container)
+	default:
+		return fmt.Errorf("unknown chaos action %s", a.Action)
+	}
+}
+
+// disconnect detaches container from all its networks remembering them for
+// connect.
+func (c *Chaos) disconnect(ctx context.Context, id string) error {
+	info, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	if info.NetworkSettings == nil || len(info.NetworkSettings.Networks) == 0 {
+		return errors.New("container is not connected to any network")
+	}
+
+	var errs []error
+	if c.networks[id] == nil {
+		c.networks[id] = make(map[string]*network.EndpointSettings)
+	}
+	for name, ep := range info.NetworkSettings.Networks {
+		if err := c.cli.NetworkDisconnect(ctx, name, id, true); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		c.networks[id][name] = &network.EndpointSettings{
+			IPAMConfig: ep.IPAMConfig,
+			Aliases:    ep.Aliases,
+			Links:      ep.Links,
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// connect attaches container back to the networks it was disconnected from.
+func (c *Chaos) connect(ctx context.Context, id string) error {
+	if len(c.networks[id]) == 0 {
+		return errors.New("container wasn't disconnected")
+	}
+
+	var errs []error
+	for name, ep := range c.networks[id] {
+		if err := c.cli.NetworkConnect(ctx, name, id, ep); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		delete(c.networks[id], name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChaosAction(t *testing.T) {
+	tests := []struct {
+		s    string
+		want ChaosAction
+		ok   bool
+	}{
+		{"1m:pause:neo_go_node_four", ChaosAction{At: time.Minute, Action: ChaosPause, Container: "neo_go_node_four"}, true},
+		{"0s:disconnect:node", ChaosAction{Action: ChaosDisconnect, Container: "node"}, true},
+		// Container is the rest of the string.
+		{"90s:kill:host:node", ChaosAction{At: 90 * time.Second, Action: ChaosKill, Container: "host:node"}, true},
+		{"1m:pause", ChaosAction{}, false},
+		{"1m:pause:", ChaosAction{}, false},
+		{"soon:pause:node", ChaosAction{}, false},
+		{"-1m:pause:node", ChaosAction{}, false},
+		{"1m:freeze:node", ChaosAction{}, false},
+	}
+	for _, tc := range tests {
+		a, err := ParseChaosAction(tc.s)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error %v", tc.s, err)
+			continue
+		}
+		if a != tc.want {
+			t.Errorf("%s: expected %+v, got %+v", tc.s, tc.want, a)
+		}
+		if tc.ok {
+			if b, err := ParseChaosAction(a.String()); err != nil || b != a {
+				t.Errorf("%s: String isn't parsed back: %+v, %v", tc.s, b, err)
+			}
+		}
+	}
+}
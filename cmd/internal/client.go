@@ -44,6 +44,9 @@ const DefaultTimeout = time.Second * 30
 var (
 	// ErrMempoolOOM is returned from `sendrawtransaction` when node cannot process transaction due to mempool OOM.
 	ErrMempoolOOM = errors.New("node cannot process transaction due to mempool OOM")
+	// ErrTxDropped is reported when transaction rejected due to mempool OOM
+	// isn't sent again before the worker stops.
+	ErrTxDropped = errors.New("transaction dropped")
 )
 
 // NewRPCClient creates new client for RPC communications.
@@ -163,6 +166,17 @@ func (c *RPCClient) GetBlockCount(ctx context.Context) (int, error) {
 	return num, c.doRPCCall(ctx, rpc, &num, c.blockRequester)
 }
 
+// GetMempoolCount sends getrawmempool RPC request and returns the number of
+// verified transactions in the node mempool.
+func (c *RPCClient) GetMempoolCount(ctx context.Context) (int, error) {
+	var hashes []util.Uint256
+	rpc := `{"jsonrpc": "2.0", "id": 1, "method": "getrawmempool", "params": []}`
+	if err := c.doRPCCall(ctx, rpc, &hashes, c.blockRequester); err != nil {
+		return 0, err
+	}
+	return len(hashes), nil
+}
+
 func (c *RPCClient) doRPCCall(_ context.Context, call string, result any, client *fasthttp.Client) error {
 	idx := c.inc.Add(1) % c.len
 
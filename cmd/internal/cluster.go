@@ -0,0 +1,649 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/moby/moby/client"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	clusterParams struct {
+		dir           string
+		healthTimeout time.Duration
+	}
+
+	// ClusterOption is an option type to configure cluster.
+	ClusterOption func(*clusterParams)
+
+	// Cluster brings up the topology in Docker containers and runs the bench
+	// against it.
+	Cluster struct {
+		*log.Logger
+
+		cli           *client.Client
+		topo          *Topology
+		dir           string
+		healthTimeout time.Duration
+
+		network    string
+		containers []string
+		rpc        []string
+		// nodeIDs are container IDs keyed by network profile node names.
+		nodeIDs map[string]string
+	}
+
+	// BenchRun describes bench container run.
+	BenchRun struct {
+		// Out is a directory reports are written to.
+		Out string
+		// Dump is a path to transactions dump, optional.
+		Dump string
+		// Args are bench arguments, RPC addresses of the cluster are added
+		// if not specified.
+		Args []string
+	}
+
+	// nodeContainer describes container of a single node.
+	nodeContainer struct {
+		name  string
+		alias string
+		// netName is the node name used by network profiles.
+		netName string
+		role    string
+		image   string
+		cmd     []string
+		binds   []string
+		env     []string
+		port    int // published RPC port, zero if not published
+		health  []string
+		retries int
+		tty     bool
+	}
+)
+
+const (
+	// ClusterNetwork is a name of the network nodes are connected to.
+	ClusterNetwork = "neo_go_network"
+	// ClusterSubnet is a subnet of the cluster network.
+	ClusterSubnet = "172.200.0.0/24"
+	// ClusterGateway is a gateway of the cluster network.
+	ClusterGateway = "172.200.0.254"
+	// NodeRPCPort is an RPC port of the nodes serving bench requests.
+	NodeRPCPort = 20331
+
+	// benchContainer is a name of the bench container.
+	benchContainer = "bench"
+)
+
+// ClusterDir sets path to the .docker directory with nodes configurations.
+func ClusterDir(dir string) ClusterOption {
+	return func(p *clusterParams) {
+		p.dir = dir
+	}
+}
+
+// ClusterHealthTimeout sets time limit for nodes to become healthy.
+func ClusterHealthTimeout(dur time.Duration) ClusterOption {
+	return func(p *clusterParams) {
+		p.healthTimeout = dur
+	}
+}
+
+// NewCluster creates Cluster of the given topology.
+func NewCluster(topo *Topology, opts ...ClusterOption) (*Cluster, error) {
+	p := &clusterParams{
+		dir:           "../.docker",
+		healthTimeout: 5 * time.Minute,
+	}
+
+	for i := range opts {
+		opts[i](p)
+	}
+
+	if err := topo.Validate(); err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(client.WithVersion("1.40")) // version mey need to be downgraded on different hosts
+	if err != nil {
+		return nil, fmt.Errorf("could not create docker client: %w", err)
+	}
+
+	return &Cluster{
+		Logger:        log.New(os.Stdout, "", log.LstdFlags),
+		cli:           cli,
+		topo:          topo,
+		dir:           dir,
+		healthTimeout: p.healthTimeout,
+	}, nil
+}
+
+// RPCAddresses returns addresses of nodes serving bench requests inside the
+// cluster network.
+func (c *Cluster) RPCAddresses() []string {
+	return c.rpc
+}
+
+// Up creates the network, starts validators and then RPC nodes waiting for
+// them to become healthy.
+func (c *Cluster) Up(ctx context.Context) error {
+	validators, rpc, err := c.nodes()
+	if err != nil {
+		return err
+	}
+
+	// Container names are fixed, so containers left by the interrupted run or
+	// started by runner.sh would conflict with the new ones.
+	names := []string{benchContainer}
+	for _, n := range slices.Concat(validators, rpc) {
+		names = append(names, n.name)
+	}
+	if err := c.removeContainers(ctx, names); err != nil {
+		return err
+	}
+
+	// Network may be left by the interrupted run, it's recreated to have the
+	// expected subnet.
+	if _, err := c.cli.NetworkInspect(ctx, ClusterNetwork, network.InspectOptions{}); err == nil {
+		c.Printf("Remove existing network %s", ClusterNetwork)
+		if err := c.cli.NetworkRemove(ctx, ClusterNetwork); err != nil {
+			return fmt.Errorf("could not remove existing network (stop containers of the previous run): %w", err)
+		}
+	} else if !client.IsErrNotFound(err) {
+		return fmt.Errorf("could not inspect network: %w", err)
+	}
+
+	c.Printf("Create network %s", ClusterNetwork)
+	resp, err := c.cli.NetworkCreate(ctx, ClusterNetwork, network.CreateOptions{
+		IPAM: &network.IPAM{Config: []network.IPAMConfig{{Subnet: ClusterSubnet, Gateway: ClusterGateway}}},
+	})
+	if err != nil {
+		return fmt.Errorf("could not create network: %w", err)
+	}
+	c.network = resp.ID
+
+	for _, group := range [][]nodeContainer{validators, rpc} {
+		var ids []string
+		for _, n := range group {
+			id, err := c.start(ctx, n)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := c.waitHealthy(ctx, ids); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunBench runs the bench container streaming its output and waits for it
+// to finish, non-zero exit code is returned as an error.
+func (c *Cluster) RunBench(ctx context.Context, run BenchRun) error {
+	out, err := filepath.Abs(run.Out)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return fmt.Errorf("could not create reports directory: %w", err)
+	}
+
+	args := run.Args
+	if !hasFlag(args, "rpcAddress", "a") {
+		for _, a := range c.rpc {
+			args = append(args, "-a", a)
+		}
+	}
+	// Reports are written to the mounted directory only.
+	if !hasFlag(args, "out", "o") {
+		args = append(args, "-o", "/out/report.log")
+	}
+
+	binds := []string{
+		out + ":/out:rw",
+		"/var/run/docker.sock:/var/run/docker.sock",
+		filepath.Join(c.dir, "rpc", "tokencontract", "token.nef") + ":/tokencontract/token.nef:ro",
+		filepath.Join(c.dir, "rpc", "tokencontract", "token.manifest.json") + ":/tokencontract/token.manifest.json:ro",
+	}
+	// Networks without well-known committee need keys config generated by
+	// cmd/config along with validator wallets.
+	if !HasDefaultCommittee(len(c.topo.Validators)) && !hasFlag(args, "keys", "") {
+		binds = append(binds, filepath.Join(c.dir, "ir")+":/ir:ro")
+		args = append(args, "--keys", "/ir/"+KeysConfigName(len(c.topo.Validators)))
+	}
+	if run.Dump != "" {
+		dump, err := filepath.Abs(run.Dump)
+		if err != nil {
+			return err
+		}
+		binds = append(binds, dump+":/dump.txs")
+		args = append(args, "-i", "/dump.txs")
+	}
+
+	id, err := c.create(ctx, nodeContainer{
+		name:  benchContainer,
+		image: c.image(c.topo.Images.Bench, DefaultBenchImage),
+		cmd:   append([]string{"neo-bench"}, args...),
+		binds: binds,
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		stdout, stderr io.Writer = os.Stdout, os.Stderr
+		started                  = make(chan time.Time, 1)
+	)
+	if len(c.topo.Network) != 0 {
+		once := new(sync.Once)
+		stdout = &benchStartWatcher{w: stdout, once: once, ch: started}
+		stderr = &benchStartWatcher{w: stderr, once: once, ch: started}
+
+		netCtx, stopNet := context.WithCancel(ctx)
+		netDone := make(chan []NetRecord, 1)
+		go func() { netDone <- c.runNetStages(netCtx, started) }()
+		defer func() {
+			stopNet()
+			records := <-netDone
+			if len(records) != 0 {
+				c.resetNetwork(ctx)
+			}
+			c.writeNetRecords(out, args, records)
+		}()
+	}
+
+	waitCh, errCh := c.cli.ContainerWait(ctx, id, container.WaitConditionNextExit)
+	if err := c.cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return fmt.Errorf("could not start bench: %w", err)
+	}
+
+	logs, err := c.cli.ContainerLogs(ctx, id, container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		return fmt.Errorf("could not fetch bench logs: %w", err)
+	}
+	// Logs are followed until the bench exits.
+	_, err = stdcopy.StdCopy(stdout, stderr, logs)
+	_ = logs.Close()
+	if err != nil && ctx.Err() == nil {
+		c.Printf("Could not stream bench logs: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errCh:
+		return fmt.Errorf("could not wait for bench: %w", err)
+	case res := <-waitCh:
+		if res.Error != nil {
+			return fmt.Errorf("bench failed: %s", res.Error.Message)
+		}
+		if res.StatusCode != 0 {
+			return fmt.Errorf("bench exited with code %d", res.StatusCode)
+		}
+	}
+	c.Printf("Reports are stored in %s", out)
+	return nil
+}
+
+// removeContainers removes existing containers with the given names.
+func (c *Cluster) removeContainers(ctx context.Context, names []string) error {
+	for _, name := range names {
+		info, err := c.cli.ContainerInspect(ctx, name)
+		if client.IsErrNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("could not inspect container %s: %w", name, err)
+		}
+		// Containers are also looked up by ID prefix.
+		if info.Name != "/"+name {
+			continue
+		}
+		c.Printf("Remove existing container %s", name)
+		err = c.cli.ContainerRemove(ctx, info.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+		if err != nil {
+			return fmt.Errorf("could not remove existing container %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Down removes all containers and the network created by the cluster.
+func (c *Cluster) Down(ctx context.Context) error {
+	var errs []error
+	for i := len(c.containers) - 1; i >= 0; i-- {
+		err := c.cli.ContainerRemove(ctx, c.containers[i], container.RemoveOptions{Force: true, RemoveVolumes: true})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not remove container: %w", err))
+		}
+	}
+	c.containers = nil
+
+	if c.network != "" {
+		if err := c.cli.NetworkRemove(ctx, c.network); err != nil {
+			errs = append(errs, fmt.Errorf("could not remove network: %w", err))
+		}
+		c.network = ""
+	}
+	c.Printf("Cluster is down")
+	return errors.Join(errs...)
+}
+
+// nodes returns containers of validators and RPC nodes along with their
+// configuration files stored in .docker/ir and .docker/rpc.
+func (c *Cluster) nodes() (validators, rpc []nodeContainer, err error) {
+	var (
+		ir     = filepath.Join(c.dir, "ir")
+		rpcDir = filepath.Join(c.dir, "rpc")
+		single = len(c.topo.Validators) == 1
+		suffix = ConfigSuffix(len(c.topo.Validators))
+	)
+
+	for i, spec := range c.topo.Validators {
+		var (
+			name   = NodeName(i)
+			config = name
+			n      = nodeContainer{role: "validator", alias: "node_" + name, netName: name, env: []string{"NEOBENCH_TC=" + c.topo.TC}}
+		)
+		if single {
+			config = "single"
+			n.alias = "node"
+			n.port = NodeRPCPort
+			c.rpc = append(c.rpc, fmt.Sprintf("node:%d", NodeRPCPort))
+		}
+
+		wallet := filepath.Join(ir, "wallet."+name+".json")
+		switch spec.Type {
+		case NodeGo:
+			n.name = "neo_go_node_" + name
+			if single {
+				n.name = "node"
+			}
+			cfg := filepath.Join(ir, "go.protocol.privnet."+config+suffix+".yml")
+			if err := c.goNode(&n, wallet, cfg); err != nil {
+				return nil, nil, err
+			}
+		case NodeSharp:
+			n.name = "neo-cli-node-" + name
+			if single {
+				n.name = "node"
+			}
+			cfg := filepath.Join(ir, "sharp.config."+config+suffix+".json")
+			c.sharpNode(&n, wallet, cfg)
+		}
+		validators = append(validators, n)
+	}
+
+	for i, spec := range c.topo.RPC {
+		n := nodeContainer{name: rpcNodeName(spec, i), role: "rpc", port: NodeRPCPort + i, env: []string{"NEOBENCH_TC="}}
+		switch spec.Type {
+		case NodeGo:
+			if err := c.goNode(&n, "", filepath.Join(rpcDir, "go.protocol"+suffix+".yml")); err != nil {
+				return nil, nil, err
+			}
+		case NodeSharp:
+			c.sharpNode(&n, "", filepath.Join(rpcDir, "sharp.config"+suffix+".json"))
+		}
+		n.alias = n.name
+		n.netName = n.name
+		c.rpc = append(c.rpc, fmt.Sprintf("%s:%d", n.name, NodeRPCPort))
+		rpc = append(rpc, n)
+	}
+
+	for _, n := range append(validators, rpc...) {
+		for _, b := range n.binds {
+			if _, err := os.Stat(strings.SplitN(b, ":", 2)[0]); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w (generate configurations with `make config`, use CONFIG_VALIDATORS for non-default networks)", n.name, err)
+			}
+		}
+	}
+
+	return validators, rpc, nil
+}
+
+func (c *Cluster) goNode(n *nodeContainer, wallet, cfg string) error {
+	port, err := goRPCPort(cfg)
+	if err != nil {
+		return fmt.Errorf("%s: %w (generate configurations with `make config`)", n.name, err)
+	}
+
+	n.image = c.image(c.topo.Images.Go, DefaultGoImage)
+	n.cmd = []string{"node", "--config-path", "/config", "--privnet"}
+	n.binds = append(n.binds, cfg+":/config/protocol.privnet.yml")
+	if wallet != "" {
+		n.binds = append(n.binds, wallet+":/config/wallet.json")
+	}
+	n.health = []string{"CMD", "sh", "-c", fmt.Sprintf("echo | nc 127.0.0.1 %d", port)}
+	n.retries = 15
+	return nil
+}
+
+func (c *Cluster) sharpNode(n *nodeContainer, wallet, cfg string) {
+	n.image = c.image(c.topo.Images.Sharp, DefaultSharpImage)
+	n.binds = append(n.binds, cfg+":/neo-cli/config.json")
+	// Plugins configurations are generated next to the node one.
+	dir, file := filepath.Split(cfg)
+	for _, p := range [][2]string{{"rpc", "RpcServer"}, {"dbft", "DBFTPlugin"}} {
+		path := dir + strings.Replace(file, "sharp.config", "sharp."+p[0], 1)
+		n.binds = append(n.binds, path+":/neo-cli/Plugins/"+p[1]+"/"+p[1]+".json")
+	}
+	if wallet != "" {
+		n.binds = append(n.binds, wallet+":/neo-cli/wallet.json")
+	}
+	n.health = []string{"CMD", "bash", "-c", "/healthcheck.sh"}
+	n.retries = 50
+	n.tty = true
+}
+
+func (c *Cluster) image(img, def string) string {
+	if img != "" {
+		return img
+	}
+	return def
+}
+
+// create creates container attached to the cluster network.
+func (c *Cluster) create(ctx context.Context, n nodeContainer) (string, error) {
+	cfg := &container.Config{
+		Image:     n.image,
+		Cmd:       n.cmd,
+		Env:       n.env,
+		Tty:       n.tty,
+		OpenStdin: n.tty,
+	}
+	host := &container.HostConfig{
+		Binds: n.binds,
+	}
+
+	if n.role != "" {
+		cfg.Labels = map[string]string{"stats": "", RoleLabel: n.role}
+		host.CapAdd = []string{"NET_ADMIN"}
+		host.LogConfig = container.LogConfig{Type: c.topo.Logger}
+	}
+	if n.health != nil {
+		cfg.Healthcheck = &container.HealthConfig{
+			Test:     n.health,
+			Interval: 5 * time.Second,
+			Timeout:  10 * time.Second,
+			Retries:  n.retries,
+		}
+	}
+	if n.port != 0 {
+		port := nat.Port(strconv.Itoa(NodeRPCPort) + "/tcp")
+		cfg.ExposedPorts = nat.PortSet{port: struct{}{}}
+		host.PortBindings = nat.PortMap{port: []nat.PortBinding{{HostPort: strconv.Itoa(n.port)}}}
+	}
+
+	var aliases []string
+	if n.alias != "" {
+		aliases = []string{n.alias}
+	}
+	netCfg := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+		ClusterNetwork: {Aliases: aliases},
+	}}
+
+	resp, err := c.cli.ContainerCreate(ctx, cfg, host, netCfg, nil, n.name)
+	if err != nil {
+		return "", fmt.Errorf("could not create %s: %w", n.name, err)
+	}
+	c.containers = append(c.containers, resp.ID)
+	return resp.ID, nil
+}
+
+func (c *Cluster) start(ctx context.Context, n nodeContainer) (string, error) {
+	id, err := c.create(ctx, n)
+	if err != nil {
+		return "", err
+	}
+	if err := c.cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("could not start %s: %w", n.name, err)
+	}
+	if n.netName != "" {
+		if c.nodeIDs == nil {
+			c.nodeIDs = make(map[string]string)
+		}
+		c.nodeIDs[n.netName] = id
+	}
+	c.Printf("Started %s (%s)", n.name, n.role)
+	return id, nil
+}
+
+// waitHealthy waits for all containers to pass healthcheck.
+func (c *Cluster) waitHealthy(ctx context.Context, ids []string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.healthTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	pending := ids
+	for len(pending) != 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("nodes haven't become healthy in %s: %w", c.healthTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+
+		var next []string
+		for _, id := range pending {
+			info, err := c.cli.ContainerInspect(ctx, id)
+			if err != nil {
+				return fmt.Errorf("could not inspect container: %w", err)
+			}
+			name := strings.TrimPrefix(info.Name, "/")
+			switch {
+			case !info.State.Running:
+				return fmt.Errorf("%s exited with code %d", name, info.State.ExitCode)
+			case info.State.Health == nil || info.State.Health.Status == container.Healthy:
+				c.Printf("%s is healthy", name)
+			case info.State.Health.Status == container.Unhealthy:
+				return fmt.Errorf("%s is unhealthy", name)
+			default:
+				next = append(next, id)
+			}
+		}
+		pending = next
+	}
+	return nil
+}
+
+// ConfigSuffix returns suffix of configuration files of the network of the
+// given size generated by cmd/config.
+func ConfigSuffix(validators int) string {
+	switch validators {
+	case 1, 4:
+		return ""
+	default:
+		return "." + strconv.Itoa(validators)
+	}
+}
+
+// goRPCPort returns RPC port of Go node from its configuration.
+func goRPCPort(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var cfg struct {
+		ApplicationConfiguration struct {
+			RPC struct {
+				Addresses []string `yaml:"Addresses"`
+			} `yaml:"RPC"`
+		} `yaml:"ApplicationConfiguration"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return 0, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+	if len(cfg.ApplicationConfiguration.RPC.Addresses) == 0 {
+		return 0, fmt.Errorf("no RPC addresses in %s", path)
+	}
+
+	addr := cfg.ApplicationConfiguration.RPC.Addresses[0]
+	port, err := strconv.Atoi(addr[strings.LastIndexByte(addr, ':')+1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid RPC address %q in %s", addr, path)
+	}
+	return port, nil
+}
+
+// flagNames returns command line names of the flag given by its long and,
+// optionally, short name.
+func flagNames(long, short string) []string {
+	names := []string{"--" + long}
+	if short != "" {
+		names = append(names, "-"+short)
+	}
+	return names
+}
+
+// flagValue returns value of the bench flag given by its long and, optionally,
+// short name.
+func flagValue(args []string, long, short string) (string, bool) {
+	for i, a := range args {
+		for _, name := range flagNames(long, short) {
+			if v, ok := strings.CutPrefix(a, name+"="); ok {
+				return v, true
+			}
+			if a == name && i+1 < len(args) {
+				return args[i+1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasFlag checks whether bench arguments contain the flag given by its long
+// and, optionally, short name.
+func hasFlag(args []string, long, short string) bool {
+	for _, a := range args {
+		for _, name := range flagNames(long, short) {
+			if a == name || strings.HasPrefix(a, name+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/moby/moby/client"
+)
+
+func TestBenchFlags(t *testing.T) {
+	tests := []struct {
+		args  []string
+		long  string
+		short string
+		value string
+		ok    bool
+	}{
+		{[]string{"-m", "rate", "-o", "/out/a.log"}, "out", "o", "/out/a.log", true},
+		{[]string{"--out=/out/b.log"}, "out", "o", "/out/b.log", true},
+		{[]string{"-o=/out/c.log"}, "out", "o", "/out/c.log", true},
+		{[]string{"-m", "rate"}, "out", "o", "", false},
+		{[]string{"--keys", "/ir/keys.yml"}, "keys", "", "/ir/keys.yml", true},
+		// Bare dash isn't a flag without short name.
+		{[]string{"-", "/ir/keys.yml"}, "keys", "", "", false},
+		{[]string{"-=/ir/keys.yml"}, "keys", "", "", false},
+	}
+	for _, tc := range tests {
+		v, ok := flagValue(tc.args, tc.long, tc.short)
+		if v != tc.value || ok != tc.ok {
+			t.Errorf("%v: expected %q (%t), got %q (%t)", tc.args, tc.value, tc.ok, v, ok)
+		}
+		if has := hasFlag(tc.args, tc.long, tc.short); has != tc.ok {
+			t.Errorf("%v: expected hasFlag %t, got %t", tc.args, tc.ok, has)
+		}
+	}
+}
+
+// newDockerServer returns Docker API server with the given containers keyed by
+// name, removed container IDs are recorded.
+func newDockerServer(t *testing.T, containers map[string]string) (*client.Client, func() []string) {
+	var (
+		mu      sync.Mutex
+		removed []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1.40/containers/")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(path, "/json"):
+			ref := strings.TrimSuffix(path, "/json")
+			for name, id := range containers {
+				// Docker resolves references by ID prefix too.
+				if ref == name || strings.HasPrefix(id, ref) {
+					_ = json.NewEncoder(w).Encode(map[string]string{"Id": id, "Name": "/" + name})
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "No such container: ` + ref + `"}`))
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			removed = append(removed, path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()),
+		client.WithHTTPClient(srv.Client()), client.WithVersion("1.40"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(removed)
+	}
+}
+
+func TestClusterRemoveContainers(t *testing.T) {
+	cli, removed := newDockerServer(t, map[string]string{
+		"bench":   "b1",
+		"go-node": "c2",
+		"other":   "abc",
+	})
+	c := &Cluster{Logger: log.New(io.Discard, "", 0), cli: cli}
+
+	if err := c.removeContainers(context.Background(), []string{"bench", "one", "go-node", "ab"}); err != nil {
+		t.Fatal(err)
+	}
+	// "ab" refers to "other" by ID prefix, it isn't a conflict.
+	if got := removed(); !slices.Equal(got, []string{"b1", "c2"}) {
+		t.Fatalf("unexpected removed containers %v", got)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -21,29 +23,67 @@ type (
 		criteria     []string
 		period       time.Duration
 		verifier     func([]container.Summary) error
+		procs        []string
 	}
 
 	// DockerStater interface.
 	DockerStater interface {
 		Run(ctx context.Context, cb StatCallback)
-		Update(ctx context.Context) (cpu, mem float64, err error)
+		Update(ctx context.Context) (total ResourceUsage, per []ContainerStat, err error)
+	}
+
+	// ResourceUsage is a resource usage by containers, I/O values are
+	// accumulated since the previous update.
+	ResourceUsage struct {
+		CPU      float64 `json:"cpu"`       // %
+		Mem      float64 `json:"mem"`       // MB
+		NetRx    float64 `json:"net_rx"`    // MB
+		NetTx    float64 `json:"net_tx"`    // MB
+		BlkRead  float64 `json:"blk_read"`  // MB
+		BlkWrite float64 `json:"blk_write"` // MB
+		// IOPS is a number of block I/O operations per second, it's zero if
+		// not provided by the cgroup driver.
+		IOPS float64 `json:"iops"`
+	}
+
+	// ContainerStat is a resource usage by a single container.
+	ContainerStat struct {
+		Name string
+		Role string
+		ResourceUsage
+	}
+
+	// ioCounters are cumulative I/O counters of container.
+	ioCounters struct {
+		at       time.Time
+		netRx    uint64
+		netTx    uint64
+		blkRead  uint64
+		blkWrite uint64
+		blkOps   uint64
 	}
 
 	dockerState struct {
 		*log.Logger
 
-		per time.Duration
-		cli *client.Client
-		cnr []container.Summary
+		per  time.Duration
+		cli  *client.Client
+		cnr  []container.Summary
+		prev map[string]ioCounters
 	}
 
 	// StatOption is an option type to configure docker state.
 	StatOption func(*dockerStateParams)
 
-	// StatCallback used to report current docker container resource usage.
-	StatCallback func(cpu, mem float64)
+	// StatCallback used to report current docker containers resource usage,
+	// total is summed over all containers.
+	StatCallback func(total ResourceUsage, per []ContainerStat)
 )
 
+// RoleLabel is a label of container which value describes the node role
+// (validator or rpc).
+const RoleLabel = "neo-bench.role"
+
 // StatCriteria sets criteria to select containers.
 func StatCriteria(criteria []string) StatOption {
 	return func(p *dockerStateParams) {
@@ -65,6 +105,15 @@ func StatPeriod(dur time.Duration) StatOption {
 	}
 }
 
+// StatProcesses sets processes to sample resources of without Docker, each
+// target is a PID or a cgroup v2 directory optionally prefixed with the node
+// role, e.g. "validator:/sys/fs/cgroup/system.slice/neo-go.service".
+func StatProcesses(targets []string) StatOption {
+	return func(p *dockerStateParams) {
+		p.procs = targets
+	}
+}
+
 // StatListVerifier sets containers list verifier.
 func StatListVerifier(verifier func([]container.Summary) error) StatOption {
 	return func(p *dockerStateParams) {
@@ -105,9 +154,14 @@ func NewStats(ctx context.Context, opts ...StatOption) (DockerStater, error) {
 		return nil, err
 	}
 
+	sort.Slice(list, func(i, j int) bool {
+		return containerName(list[i]) < containerName(list[j])
+	})
+
 	ds := &dockerState{
 		cnr:    list,
 		cli:    cli,
+		prev:   make(map[string]ioCounters, len(list)),
 		per:    p.period,
 		Logger: log.New(os.Stdout, "", log.LstdFlags),
 	}
@@ -124,41 +178,92 @@ func NewStats(ctx context.Context, opts ...StatOption) (DockerStater, error) {
 }
 
 // Update returns current resource usage by containers.
-func (s *dockerState) Update(ctx context.Context) (cpu, mem float64, err error) {
-	var (
-		result container.StatsResponseReader
-		stats  container.StatsResponse
-	)
+func (s *dockerState) Update(ctx context.Context) (total ResourceUsage, per []ContainerStat, err error) {
+	var result container.StatsResponseReader
 
+	per = make([]ContainerStat, 0, len(s.cnr))
 	for i := range s.cnr {
-		id := s.cnr[i].ID
+		var (
+			id    = s.cnr[i].ID
+			stats container.StatsResponse
+		)
 
 		if result, err = s.cli.ContainerStats(ctx, id, false); err != nil {
-			cpu = 0
-			mem = 0
-			return
+			return ResourceUsage{}, nil, err
 		}
 
-		if err = json.NewDecoder(result.Body).Decode(&stats); err != nil {
-			cpu = 0
-			mem = 0
-			return
+		err = json.NewDecoder(result.Body).Decode(&stats)
+		_ = result.Body.Close()
+		if err != nil {
+			return ResourceUsage{}, nil, err
 		}
 
 		// Update state
-		curCPU, curMem := usage(&stats)
+		var cur ResourceUsage
+		cur.CPU, cur.Mem = usage(&stats)
 
-		cpu += curCPU
-		mem += curMem
+		counters := ioUsage(&stats)
+		if prev, ok := s.prev[id]; ok {
+			counters.since(prev, &cur)
+		}
+		s.prev[id] = counters
+
+		total.add(cur)
+		per = append(per, ContainerStat{
+			Name:          containerName(s.cnr[i]),
+			Role:          containerRole(s.cnr[i]),
+			ResourceUsage: cur,
+		})
 	}
 
 	return
 }
 
+// since sets I/O usage accumulated since the previous counters.
+func (c ioCounters) since(prev ioCounters, u *ResourceUsage) {
+	u.NetRx = toMB(c.netRx, prev.netRx)
+	u.NetTx = toMB(c.netTx, prev.netTx)
+	u.BlkRead = toMB(c.blkRead, prev.blkRead)
+	u.BlkWrite = toMB(c.blkWrite, prev.blkWrite)
+	if dt := c.at.Sub(prev.at).Seconds(); dt > 0 && c.blkOps >= prev.blkOps {
+		u.IOPS = float64(c.blkOps-prev.blkOps) / dt
+	}
+}
+
+func (u *ResourceUsage) add(v ResourceUsage) {
+	u.CPU += v.CPU
+	u.Mem += v.Mem
+	u.NetRx += v.NetRx
+	u.NetTx += v.NetTx
+	u.BlkRead += v.BlkRead
+	u.BlkWrite += v.BlkWrite
+	u.IOPS += v.IOPS
+}
+
+func containerName(c container.Summary) string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+func containerRole(c container.Summary) string {
+	if role, ok := c.Labels[RoleLabel]; ok {
+		return role
+	}
+	return "unknown"
+}
+
 // Run worker to periodically fetching resource usage by containers.
 func (s *dockerState) Run(ctx context.Context, cb StatCallback) {
+	runStats(ctx, s.Logger, s.per, s.Update, cb)
+}
+
+// runStats periodically fetches resource usage with update and passes it to cb.
+func runStats(ctx context.Context, l *log.Logger, period time.Duration,
+	update func(context.Context) (ResourceUsage, []ContainerStat, error), cb StatCallback) {
 	done := ctx.Done()
-	tick := time.NewTimer(s.per)
+	tick := time.NewTimer(period)
 
 loop:
 	for {
@@ -166,16 +271,16 @@ loop:
 		case <-done:
 			break loop
 		case <-tick.C:
-			cpu, mem, err := s.Update(ctx)
+			total, per, err := update(ctx)
 			if errors.Is(err, context.Canceled) {
 				break loop
 			} else if err != nil {
-				s.Printf("Something went wrong: %v", err)
+				l.Printf("Something went wrong: %v", err)
 			}
 
-			cb(cpu, mem)
+			cb(total, per)
 
-			tick.Reset(s.per)
+			tick.Reset(period)
 		}
 	}
 }
@@ -200,3 +305,43 @@ func usage(s *container.StatsResponse) (cpu, mem float64) {
 
 	return
 }
+
+func ioUsage(s *container.StatsResponse) ioCounters {
+	c := ioCounters{at: s.Read}
+	if c.at.IsZero() {
+		c.at = time.Now()
+	}
+
+	for _, n := range s.Networks {
+		c.netRx += n.RxBytes
+		c.netTx += n.TxBytes
+	}
+
+	for _, e := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			c.blkRead += e.Value
+		case "write":
+			c.blkWrite += e.Value
+		}
+	}
+
+	// I/O operations are provided for cgroup v1 only.
+	for _, e := range s.BlkioStats.IoServicedRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read", "write":
+			c.blkOps += e.Value
+		}
+	}
+
+	return c
+}
+
+// toMB returns counter increment in megabytes, counter reset (e.g. on container
+// restart) is treated as no increment.
+func toMB(cur, prev uint64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / 1024 / 1024
+}
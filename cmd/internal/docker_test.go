@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestContainerRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		role   string
+	}{
+		{"validator", map[string]string{"stats": "", RoleLabel: "validator"}, "validator"},
+		{"rpc", map[string]string{RoleLabel: "rpc"}, "rpc"},
+		{"no label", map[string]string{"stats": ""}, "unknown"},
+		{"no labels", nil, "unknown"},
+		{"empty role", map[string]string{RoleLabel: ""}, ""},
+	}
+	for _, tc := range tests {
+		if role := containerRole(container.Summary{Labels: tc.labels}); role != tc.role {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.role, role)
+		}
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	tests := []struct {
+		c    container.Summary
+		name string
+	}{
+		{container.Summary{ID: "0123456789abcdef", Names: []string{"/neo_go_node_one", "/alias"}}, "neo_go_node_one"},
+		{container.Summary{ID: "0123456789abcdef"}, "0123456789abcdef"},
+	}
+	for _, tc := range tests {
+		if name := containerName(tc.c); name != tc.name {
+			t.Errorf("expected %s, got %s", tc.name, name)
+		}
+	}
+}
+
+func TestNewProcTargetRole(t *testing.T) {
+	// Cgroup path may contain colons, they aren't taken for the role then.
+	cgroup := filepath.Join(t.TempDir(), "neo:go")
+	if err := os.Mkdir(cgroup, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cgroup, "cpu.stat"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		target string
+		name   string
+		role   string
+	}{
+		{"validator:" + cgroup, "neo:go", "validator"},
+		{cgroup, "neo:go", "unknown"},
+		{"rpc:" + pid, "-" + pid, "rpc"},
+		{pid, "-" + pid, "unknown"},
+	}
+	for _, tc := range tests {
+		target, err := newProcTarget(tc.target)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.target, err)
+		}
+		// Process name starts with its command which depends on the test binary.
+		if target.role != tc.role || !strings.HasSuffix(target.name, tc.name) {
+			t.Errorf("%s: unexpected target %+v", tc.target, target)
+		}
+	}
+
+	if _, err := newProcTarget("validator:" + filepath.Join(cgroup, "missing")); err == nil {
+		t.Fatal("expected error for missing cgroup")
+	}
+}
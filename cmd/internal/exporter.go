@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nspcc-dev/neo-go/pkg/neorpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+)
+
+// Exporter exposes live benchmark metrics in Prometheus format.
+type Exporter struct {
+	srv *http.Server
+
+	sent       prometheus.Counter
+	errs       *prometheus.CounterVec
+	oomRetries prometheus.Counter
+	rps        prometheus.Gauge
+	blockTPS   prometheus.Gauge
+	parsed     prometheus.Counter
+	blocks     prometheus.Counter
+}
+
+// Error classes of Tx submission.
+const (
+	ErrClassRejected  = "rejected"
+	ErrClassTimeout   = "timeout"
+	ErrClassTransport = "transport"
+	ErrClassDropped   = "dropped"
+)
+
+// NewExporter creates Exporter listening on the given address.
+func NewExporter(addr string) *Exporter {
+	const namespace = "neobench"
+
+	e := &Exporter{
+		sent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sent_tx_total",
+			Help:      "Number of transactions sent successfully.",
+		}),
+		errs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "send_errors_total",
+			Help:      "Number of failed transaction submissions by error class.",
+		}, []string{"class"}),
+		oomRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mempool_oom_retries_total",
+			Help:      "Number of transactions queued for retransmission due to mempool OOM.",
+		}),
+		rps: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rps",
+			Help:      "Current average number of transactions sent per second.",
+		}),
+		blockTPS: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "block_tps",
+			Help:      "TPS of the last parsed block.",
+		}),
+		parsed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parsed_tx_total",
+			Help:      "Number of transactions found in parsed blocks.",
+		}),
+		blocks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "parsed_blocks_total",
+			Help:      "Number of parsed blocks.",
+		}),
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(e.sent, e.errs, e.oomRetries, e.rps, e.blockTPS, e.parsed, e.blocks)
+	for _, class := range []string{ErrClassRejected, ErrClassTimeout, ErrClassTransport, ErrClassDropped} {
+		e.errs.WithLabelValues(class)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	e.srv = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: DefaultTimeout,
+		ErrorLog:          log.New(os.Stdout, "", log.LstdFlags),
+	}
+
+	return e
+}
+
+// Run serves metrics until the context is done.
+func (e *Exporter) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		// Give the last scrape a chance to finish.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = e.srv.Shutdown(shutdownCtx) //nolint:contextcheck // contextcheck: Non-inherited new context, use function like `context.WithXXX` instead
+	}()
+
+	log.Printf("Serve metrics on %s", e.srv.Addr)
+	if err := e.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("could not serve metrics: %v", err)
+	}
+}
+
+// UpdateSend accounts result of Tx submission.
+func (e *Exporter) UpdateSend(err error) {
+	switch {
+	case err == nil:
+		e.sent.Inc()
+	case errors.Is(err, ErrMempoolOOM):
+		e.oomRetries.Inc()
+	default:
+		e.errs.WithLabelValues(ErrorClass(err)).Inc()
+	}
+}
+
+// UpdateRPS sets current average rps rate.
+func (e *Exporter) UpdateRPS(v float64) {
+	e.rps.Set(v)
+}
+
+// UpdateTPS accounts parsed block.
+func (e *Exporter) UpdateTPS(_ uint64, txCount int, v float64) {
+	e.blocks.Inc()
+	e.parsed.Add(float64(txCount))
+	e.blockTPS.Set(v)
+}
+
+// ErrorClass returns class of Tx submission error.
+func ErrorClass(err error) string {
+	var rpcErr *neorpc.Error
+	switch {
+	case errors.Is(err, ErrTxDropped):
+		return ErrClassDropped
+	case errors.As(err, &rpcErr):
+		return ErrClassRejected
+	case errors.Is(err, fasthttp.ErrTimeout), errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+		return ErrClassTimeout
+	default:
+		return ErrClassTransport
+	}
+}
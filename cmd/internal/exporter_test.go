@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nspcc-dev/neo-go/pkg/neorpc"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+func TestExporterMetrics(t *testing.T) {
+	e := NewExporter("127.0.0.1:0")
+	srv := httptest.NewServer(e.srv.Handler)
+	t.Cleanup(srv.Close)
+
+	for range 3 {
+		e.UpdateSend(nil)
+	}
+	e.UpdateSend(fmt.Errorf("send: %w", ErrMempoolOOM))
+	e.UpdateSend(neorpc.NewInvalidParamsError("bad tx"))
+	e.UpdateSend(ErrTxDropped)
+	e.UpdateSend(context.DeadlineExceeded)
+	e.UpdateSend(errors.New("connection refused"))
+	e.UpdateRPS(123.5)
+	e.UpdateTPS(1000, 10, 10)
+	e.UpdateTPS(1000, 20, 20)
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %s", resp.Status)
+	}
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for name, mf := range families {
+		for _, m := range mf.GetMetric() {
+			key := name + seriesLabels(m.GetLabel())
+			if c := m.GetCounter(); c != nil {
+				values[key] = c.GetValue()
+			} else {
+				values[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"neobench_sent_tx_total":                        3,
+		"neobench_mempool_oom_retries_total":            1,
+		`neobench_send_errors_total{class="rejected"}`:  1,
+		`neobench_send_errors_total{class="dropped"}`:   1,
+		`neobench_send_errors_total{class="timeout"}`:   1,
+		`neobench_send_errors_total{class="transport"}`: 1,
+		"neobench_rps":                 123.5,
+		"neobench_block_tps":           20,
+		"neobench_parsed_tx_total":     30,
+		"neobench_parsed_blocks_total": 2,
+	}
+	if len(values) != len(want) {
+		t.Errorf("expected %d series, got %v", len(want), values)
+	}
+	for name, v := range want {
+		if got, ok := values[name]; !ok || got != v {
+			t.Errorf("%s: expected %v, got %v (exposed: %t)", name, v, got, ok)
+		}
+	}
+}
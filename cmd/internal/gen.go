@@ -2,10 +2,13 @@ package internal
 
 import (
 	"context"
+	crand "crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"runtime"
 	"strings"
 	"sync"
@@ -13,6 +16,8 @@ import (
 
 	"github.com/Workiva/go-datastructures/queue"
 	"github.com/nspcc-dev/neo-go/pkg/config/netmode"
+	"github.com/nspcc-dev/neo-go/pkg/core/fee"
+	"github.com/nspcc-dev/neo-go/pkg/core/interop"
 	"github.com/nspcc-dev/neo-go/pkg/core/transaction"
 	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
 	"github.com/nspcc-dev/neo-go/pkg/interop/native/gas"
@@ -20,6 +25,7 @@ import (
 	"github.com/nspcc-dev/neo-go/pkg/io"
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract/callflag"
 	"github.com/nspcc-dev/neo-go/pkg/util"
+	"github.com/nspcc-dev/neo-go/pkg/vm"
 	"github.com/nspcc-dev/neo-go/pkg/vm/emit"
 	"github.com/nspcc-dev/neo-go/pkg/vm/opcode"
 	"github.com/nspcc-dev/neo-go/pkg/wallet"
@@ -30,6 +36,10 @@ type (
 	Dump struct {
 		BenchOptions      BenchOptions
 		TransactionsQueue *queue.RingBuffer
+
+		// generated is closed when all transactions are generated, it's nil
+		// for dumps that are generated in advance.
+		generated chan struct{}
 	}
 
 	// GenerateCallback used to do something with hash and marshaled transactions when generates.
@@ -40,8 +50,10 @@ type (
 	}
 
 	txRequest struct {
-		tx  *transaction.Transaction
-		acc *wallet.Account
+		nonce    uint32
+		vub      uint32
+		sender   int
+		receiver util.Uint160
 	}
 )
 
@@ -52,21 +64,57 @@ const (
 	GASTransfer = "gas"
 	// ContractTransfer is the type of deployed NEP17 contract transfer tx.
 	ContractTransfer = "nep17"
+
+	// FirstSenderWIF is the WIF of the first tx sender, the rest of senders are generated.
+	FirstSenderWIF = "KxhEDBQyyEFymvfJD96q8stMbJMbZUb6D1PmXqBWZDU2WvbvVs9o"
+
+	// DistRoundRobin cycles over accounts, it's the default accounts distribution.
+	DistRoundRobin = "roundrobin"
+	// DistUniform picks accounts uniformly at random.
+	DistUniform = "uniform"
+	// DistZipf picks accounts following Zipf distribution, so that there are some hot accounts.
+	DistZipf = "zipf"
+	// DistFresh uses a new receiver address for every tx.
+	DistFresh = "fresh"
+
+	// ScopeCalledByEntry is the default witness scope of tx signers.
+	ScopeCalledByEntry = "entry"
+	// ScopeCustomContracts limits signers' witnesses to the transferred token contract.
+	ScopeCustomContracts = "contracts"
+	// ScopeCustomGroups makes witness check look up contract groups, it falls back
+	// to the token contract rule since native contracts have no groups.
+	ScopeCustomGroups = "groups"
+	// ScopeWitnessRules limits signers' witnesses with a set of witness rules.
+	ScopeWitnessRules = "rules"
+	// ScopeGlobal is the global witness scope of tx signers.
+	ScopeGlobal = "global"
 )
 
+// ValidateWitnessScope checks whether the given witness scope is supported by generator.
+func ValidateWitnessScope(scope string) error {
+	switch strings.ToLower(scope) {
+	case ScopeCalledByEntry, ScopeCustomContracts, ScopeCustomGroups, ScopeWitnessRules, ScopeGlobal:
+		return nil
+	default:
+		return fmt.Errorf("invalid witness scope: %s", scope)
+	}
+}
+
 // newNEOTransferTx returns NEO transfer transaction with random nonce.
-func newNEOTransferTx(p *keys.PrivateKey, to util.Uint160) *transaction.Transaction {
+func newNEOTransferTx(p *keys.PrivateKey, to util.Uint160, cosigners []*keys.PrivateKey, scope string) *transaction.Transaction {
 	neoContractHash, _ := util.Uint160DecodeBytesBE([]byte(neo.Hash))
-	return newTransferTx(p, neoContractHash, to)
+	return newTransferTx(p, neoContractHash, to, cosigners, scope)
 }
 
 // newGASTransferTx returns GAS transfer transaction with random nonce.
-func newGASTransferTx(p *keys.PrivateKey, to util.Uint160) *transaction.Transaction {
+func newGASTransferTx(p *keys.PrivateKey, to util.Uint160, cosigners []*keys.PrivateKey, scope string) *transaction.Transaction {
 	gasContractHash, _ := util.Uint160DecodeBytesBE([]byte(gas.Hash))
-	return newTransferTx(p, gasContractHash, to)
+	return newTransferTx(p, gasContractHash, to, cosigners, scope)
 }
 
-func newTransferTx(p *keys.PrivateKey, contractHash, toAddr util.Uint160) *transaction.Transaction {
+// newTransferTx returns transfer transaction signed by p and additional cosigners,
+// every signer gets the witness scope specified by scope.
+func newTransferTx(p *keys.PrivateKey, contractHash, toAddr util.Uint160, cosigners []*keys.PrivateKey, scope string) *transaction.Transaction {
 	fromAddressHash := p.GetScriptHash()
 	w := io.NewBufBinWriter()
 	emit.AppCall(w.BinWriter,
@@ -79,27 +127,325 @@ func newTransferTx(p *keys.PrivateKey, contractHash, toAddr util.Uint160) *trans
 
 	script := w.Bytes()
 	tx := transaction.New(script, 15000000)
-	tx.NetworkFee = 1500000 // hardcoded for now
-	tx.ValidUntilBlock = 1200
-	tx.Signers = append(tx.Signers, transaction.Signer{
-		Account: fromAddressHash,
-		Scopes:  transaction.CalledByEntry,
-	})
+	tx.Signers = append(tx.Signers, newTransferSigner(p, contractHash, scope))
+	for _, c := range cosigners {
+		tx.Signers = append(tx.Signers, newTransferSigner(c, contractHash, scope))
+	}
+	setNetworkFee(tx, append([]*keys.PrivateKey{p}, cosigners...))
 	return tx
 }
 
+// Default Policy contract values used to calculate network fee of generated
+// transactions, they're not changed in the benchmarked networks.
+const (
+	defaultExecFeeFactor = interop.DefaultBaseExecFee * vm.ExecFeeFactorMultiplier
+	defaultFeePerByte    = 1000
+)
+
+// setNetworkFee sets network fee of tx witnessed by signature contracts of the
+// given keys: verification price of every witness and the size of transaction
+// including witnesses, so that signers' scopes and rules are accounted for.
+func setNetworkFee(tx *transaction.Transaction, signers []*keys.PrivateKey) {
+	var (
+		netFee int64
+		size   = io.GetVarSize(tx)
+	)
+	for _, p := range signers {
+		f, s := fee.Calculate(defaultExecFeeFactor, p.PublicKey().GetVerificationScript())
+		netFee += f
+		size += s
+	}
+	tx.NetworkFee = netFee + int64(size)*defaultFeePerByte
+}
+
+// newTransferSigner returns signer for p with the specified witness scope that
+// allows p's witness to be checked by the contract with the given hash.
+func newTransferSigner(p *keys.PrivateKey, contractHash util.Uint160, scope string) transaction.Signer {
+	s := transaction.Signer{Account: p.GetScriptHash()}
+	contractRule := transaction.WitnessRule{
+		Action: transaction.WitnessAllow,
+		Condition: &transaction.ConditionAnd{
+			&transaction.ConditionCalledByEntry{},
+			(*transaction.ConditionScriptHash)(&contractHash),
+		},
+	}
+
+	switch strings.ToLower(scope) {
+	case ScopeCustomContracts:
+		s.Scopes = transaction.CustomContracts
+		s.AllowedContracts = []util.Uint160{contractHash}
+	case ScopeCustomGroups:
+		s.Scopes = transaction.CustomGroups | transaction.Rules
+		s.AllowedGroups = []*keys.PublicKey{p.PublicKey()}
+		s.Rules = []transaction.WitnessRule{contractRule}
+	case ScopeWitnessRules:
+		s.Scopes = transaction.Rules
+		s.Rules = []transaction.WitnessRule{
+			{
+				Action:    transaction.WitnessDeny,
+				Condition: (*transaction.ConditionGroup)(p.PublicKey()),
+			},
+			contractRule,
+		}
+	case ScopeGlobal:
+		s.Scopes = transaction.Global
+	default:
+		s.Scopes = transaction.CalledByEntry
+	}
+	return s
+}
+
 var genWorkerCount = runtime.NumCPU()
 
+// defaultValidUntilBlock is ValidUntilBlock of transactions generated in advance.
+const defaultValidUntilBlock = 1200
+
+// dumpPollTimeout is the time to wait for transactions generated on the fly.
+const dumpPollTimeout = 100 * time.Millisecond
+
+// count returns the number of transactions in the dump.
+func (d *Dump) count() int {
+	if d.generated != nil {
+		return int(d.BenchOptions.TxCount)
+	}
+	return int(d.TransactionsQueue.Len())
+}
+
+// next returns the next transaction from the dump, it waits for transactions
+// generated on the fly if needed. ok is false when there are no transactions
+// left or the context is done.
+func (d *Dump) next(ctx context.Context) (tx string, ok bool) {
+	for ctx.Err() == nil {
+		if d.TransactionsQueue.Len() == 0 && !d.generating() {
+			return "", false
+		}
+		item, err := d.TransactionsQueue.Poll(dumpPollTimeout)
+		if err == nil {
+			return item.(string), true
+		}
+		if !errors.Is(err, queue.ErrTimeout) {
+			log.Fatalf("cannot dequeue transaction: %s", err)
+		}
+	}
+	return "", false
+}
+
+// generating checks whether transactions are still being generated.
+func (d *Dump) generating() bool {
+	if d.generated == nil {
+		return false
+	}
+	select {
+	case <-d.generated:
+		return false
+	default:
+		return true
+	}
+}
+
+// Streams of the random generator seeded with BenchOptions.Seed, they make
+// generated keys independent of accounts picking.
+const (
+	seedStreamKeys uint64 = iota
+	seedStreamAccounts
+)
+
+// NewSeed returns random seed for transactions generation.
+func NewSeed() uint64 {
+	buf := make([]byte, 8)
+	_, _ = crand.Read(buf)
+	return binary.BigEndian.Uint64(buf)
+}
+
+// NewSenders returns count senders starting from the first one, the rest of
+// keys are derived from seed.
+func NewSenders(first *keys.PrivateKey, count int, seed uint64) []*keys.PrivateKey {
+	var (
+		rnd     = rand.New(rand.NewPCG(seed, seedStreamKeys))
+		buf     = make([]byte, 32)
+		senders = make([]*keys.PrivateKey, 0, count)
+	)
+	senders = append(senders, first)
+	for len(senders) < count {
+		for i := 0; i < len(buf); i += 8 {
+			binary.BigEndian.PutUint64(buf[i:], rnd.Uint64())
+		}
+		p, err := keys.NewPrivateKeyFromBytes(buf)
+		if err != nil {
+			// Out of curve order, just take the next bytes.
+			continue
+		}
+		senders = append(senders, p)
+	}
+	return senders
+}
+
+// zipfExponent is the exponent of Zipf distribution, the larger it is the
+// more txs hit the hottest accounts.
+const zipfExponent = 1.1
+
+// ValidateDistribution checks whether the given accounts distribution is supported
+// by generator, fresh addresses are only allowed for receivers.
+func ValidateDistribution(dist string, receivers bool) error {
+	switch strings.ToLower(dist) {
+	case DistRoundRobin, DistUniform, DistZipf:
+		return nil
+	case DistFresh:
+		if receivers {
+			return nil
+		}
+		return errors.New("fresh addresses can't be used as tx senders")
+	default:
+		return fmt.Errorf("invalid accounts distribution: %s", dist)
+	}
+}
+
+// newAccountPicker returns function that returns index of the account used by
+// i-th transaction. Indexes are in [0, n) range except for fresh addresses which
+// are unique for every transaction and start from n.
+func newAccountPicker(dist string, n, shift int, rnd *rand.Rand) func(i int) int {
+	switch strings.ToLower(dist) {
+	case DistUniform:
+		return func(int) int { return rnd.IntN(n) }
+	case DistZipf:
+		z := rand.NewZipf(rnd, zipfExponent, 1, uint64(n-1))
+		return func(int) int { return int(z.Uint64()) }
+	case DistFresh:
+		return func(i int) int { return n + i }
+	default:
+		return func(i int) int { return (i + shift) % n }
+	}
+}
+
+// receiverAddress returns the address of idx-th receiver, the first receivers
+// are senders and the rest are generated from index, so there is no
+// limit on the number of receivers.
+func receiverAddress(senders []*keys.PrivateKey, idx int) util.Uint160 {
+	if idx < len(senders) {
+		return senders[idx].GetScriptHash()
+	}
+	var receiver util.Uint160
+	binary.LittleEndian.PutUint64(receiver[:], uint64(idx))
+	return receiver
+}
+
+// newTxBuilder returns function that creates transfer transaction from the
+// sender with the given index along with accounts needed to sign it.
+func newTxBuilder(opts BenchOptions) func(sender int, receiver util.Uint160) (*transaction.Transaction, []*wallet.Account) {
+	accs := make([]*wallet.Account, len(opts.Senders))
+	for i := range accs {
+		accs[i] = wallet.NewAccountFromPrivateKey(opts.Senders[i])
+	}
+
+	return func(sender int, receiver util.Uint160) (*transaction.Transaction, []*wallet.Account) {
+		signers := make([]*wallet.Account, 0, max(opts.SignersCount, 1))
+		cosigners := make([]*keys.PrivateKey, 0, max(opts.SignersCount-1, 0))
+		signers = append(signers, accs[sender])
+		for j := 1; j < opts.SignersCount; j++ {
+			k := (sender + j) % len(opts.Senders)
+			cosigners = append(cosigners, opts.Senders[k])
+			signers = append(signers, accs[k])
+		}
+
+		var (
+			from = opts.Senders[sender]
+			tx   *transaction.Transaction
+		)
+		switch strings.ToLower(opts.TransferType) {
+		case NEOTransfer:
+			tx = newNEOTransferTx(from, receiver, cosigners, opts.WitnessScope)
+		case GASTransfer:
+			tx = newGASTransferTx(from, receiver, cosigners, opts.WitnessScope)
+		case ContractTransfer:
+			h, _ := util.Uint160DecodeStringLE("ceb508fc02abc2dc27228e21976699047bbbcce0")
+			tx = newTransferTx(from, h, receiver, cosigners, opts.WitnessScope)
+		default:
+			panic(fmt.Sprintf("invalid type: %s", opts.TransferType))
+		}
+		return tx, signers
+	}
+}
+
 // Generate used to generate the specified number of transactions.
 func Generate(ctx context.Context, opts BenchOptions, callback ...GenerateCallback) *Dump {
 	start := time.Now()
-	count := int(opts.TxCount)
 
 	dump := Dump{
 		TransactionsQueue: queue.NewRingBuffer(opts.TxCount),
 	}
 
-	log.Printf("Generate %d txs", count)
+	log.Printf("Generate %d txs", opts.TxCount)
+
+	generate(ctx, opts, func() uint32 { return defaultValidUntilBlock }, func(i int, r txBlob) {
+		err := dump.TransactionsQueue.Put(r.blob)
+		if err != nil {
+			log.Fatalf("Cannot enqueue transaction #%d: %s", i, err)
+		}
+
+		for j := range callback {
+			if err := callback[j](r.hash, r.blob); err != nil {
+				log.Fatalf("Callback returns error: %d %v", i, err)
+			}
+		}
+	})
+	if ctx.Err() != nil {
+		log.Fatal(ctx.Err())
+	}
+
+	log.Printf("Done: %s", time.Since(start))
+	return &dump
+}
+
+// NewStreamDump returns dump of the specified number of transactions generated
+// on the fly by GenerateStream. Transactions are put into the dump queue of the
+// given capacity, so generation is throttled by the queue consumers.
+func NewStreamDump(opts BenchOptions, capacity uint64) *Dump {
+	return &Dump{
+		BenchOptions:      opts,
+		TransactionsQueue: queue.NewRingBuffer(capacity),
+		generated:         make(chan struct{}),
+	}
+}
+
+// GenerateStream starts generation of the dump transactions in background. vub
+// returns ValidUntilBlock for the next transaction and rateReporter is
+// periodically called with the generation rate which doesn't include the time
+// spent waiting for the queue.
+func GenerateStream(ctx context.Context, dump *Dump, vub func() uint32, rateReporter func(float64)) {
+	opts := dump.BenchOptions
+	log.Printf("Generate %d txs on the fly", opts.TxCount)
+
+	go func() {
+		defer close(dump.generated)
+
+		var (
+			start      = time.Now()
+			lastReport = start
+			waiting    time.Duration
+		)
+		generate(ctx, opts, vub, func(i int, r txBlob) {
+			putStart := time.Now()
+			err := dump.TransactionsQueue.Put(r.blob)
+			if err != nil {
+				log.Fatalf("Cannot enqueue transaction #%d: %s", i, err)
+			}
+			waiting += time.Since(putStart)
+
+			if time.Since(lastReport) < time.Second && i+1 != int(opts.TxCount) {
+				return
+			}
+			rate := float64(i+1) / (time.Since(start) - waiting).Seconds()
+			rateReporter(rate)
+			log.Printf("Generated %d txs, %0.3f tx/s, %d txs are buffered", i+1, rate, dump.TransactionsQueue.Len())
+			lastReport = time.Now()
+		})
+	}()
+}
+
+// generate generates the specified number of transactions and passes them to
+// put in order. It stops early if the context is canceled.
+func generate(ctx context.Context, opts BenchOptions, vub func() uint32, put func(i int, r txBlob)) {
+	count := int(opts.TxCount)
 
 	txCh := make([]chan txRequest, genWorkerCount)
 	for i := range txCh {
@@ -110,109 +456,85 @@ func Generate(ctx context.Context, opts BenchOptions, callback ...GenerateCallba
 		result[i] = make(chan txBlob, 1)
 	}
 
+	build := newTxBuilder(opts)
+
 	var wg sync.WaitGroup
 	for i := range genWorkerCount {
 		wg.Go(func() {
-			genTxWorker(i, txCh[i], result[i])
+			genTxWorker(build, txCh[i], result[i])
 		})
 	}
 
-	// We support both N-to-1 and 1-to-N cases, thus the size is adjusted.
-	txR := make([]txRequest, max(len(opts.Senders), opts.ToCount))
-	for i := range txR {
-		sender := opts.Senders[i%len(opts.Senders)]
-		receiver := opts.Senders[0].GetScriptHash()
-		if opts.ToCount > 1 {
-			rem := (i + 1) % opts.ToCount
-			if rem < len(opts.Senders) {
-				receiver = opts.Senders[rem].GetScriptHash()
-			} else { // support up to 65536 receivers
-				receiver = util.Uint160{}
-				binary.LittleEndian.PutUint16(receiver[:], uint16(rem))
-			}
-		}
+	log.Printf("Seed: %d", opts.Seed)
+	rnd := rand.New(rand.NewPCG(opts.Seed, seedStreamAccounts))
 
-		var tx *transaction.Transaction
-		switch strings.ToLower(opts.TransferType) {
-		case NEOTransfer:
-			tx = newNEOTransferTx(sender, receiver)
-		case GASTransfer:
-			tx = newGASTransferTx(sender, receiver)
-		case ContractTransfer:
-			h, _ := util.Uint160DecodeStringLE("ceb508fc02abc2dc27228e21976699047bbbcce0")
-			tx = newTransferTx(sender, h, receiver)
-		default:
-			panic(fmt.Sprintf("invalid type: %s", opts.TransferType))
-		}
-		txR[i].tx = tx
-		txR[i].acc = wallet.NewAccountFromPrivateKey(sender)
+	// We support both N-to-1 and 1-to-N cases, receivers are shifted by one
+	// so that round-robin sender doesn't send to itself.
+	pickSender := newAccountPicker(opts.SendersDistribution, len(opts.Senders), 0, rnd)
+	receivers := max(opts.ToCount, 1)
+	if strings.EqualFold(opts.ReceiversDistribution, DistFresh) {
+		// Fresh receivers never intersect with senders and regular receivers.
+		receivers = max(opts.ToCount, len(opts.Senders))
 	}
+	pickReceiver := newAccountPicker(opts.ReceiversDistribution, receivers, 1, rnd)
 
-	finishCh := make(chan struct{})
 	go func() {
 		for i := range count {
 			if ctx.Err() != nil {
-				log.Fatal(ctx.Err())
+				break
 			}
 
-			txCh[i%len(txCh)] <- txR[i%len(txR)]
+			// Nonce depends on tx index only, so that the result doesn't
+			// depend on the number of generating workers.
+			txCh[i%len(txCh)] <- txRequest{
+				nonce:    uint32(opts.Seed) ^ uint32(i),
+				vub:      vub(),
+				sender:   pickSender(i),
+				receiver: receiverAddress(opts.Senders, pickReceiver(i)),
+			}
 		}
 		for _, ch := range txCh {
 			close(ch)
 		}
-		close(finishCh)
+		wg.Wait()
+		for _, ch := range result {
+			close(ch)
+		}
 	}()
 
+	// Results are ordered, so the first closed channel means there are no more txs.
 	for i := range count {
-		r := <-result[i%len(result)]
-
-		err := dump.TransactionsQueue.Put(r.blob)
-		if err != nil {
-			log.Fatalf("Cannot enqueue transaction #%d: %s", i, err)
-		}
-
-		for j := range callback {
-			if err := callback[j](r.hash, r.blob); err != nil {
-				log.Fatalf("Callback returns error: %d %v", i, err)
-			}
+		r, ok := <-result[i%len(result)]
+		if !ok {
+			return
 		}
+		put(i, r)
 	}
-
-	<-finishCh
-	wg.Wait()
-	for _, ch := range result {
-		close(ch)
-	}
-
-	log.Printf("Done: %s", time.Since(start))
-	return &dump
 }
 
-func genTxWorker(n int, ch <-chan txRequest, out chan<- txBlob) {
-	baseNonce := n << 24 // 255 possible workers and 16M transactions should be enough
-	i := 0
-
+func genTxWorker(build func(int, util.Uint160) (*transaction.Transaction, []*wallet.Account), ch <-chan txRequest, out chan<- txBlob) {
 	buf := io.NewBufBinWriter()
 	for tr := range ch {
-		tx := *tr.tx
-		tx.Nonce = uint32(baseNonce | i)
+		tx, accs := build(tr.sender, tr.receiver)
+		tx.Nonce = tr.nonce
+		tx.ValidUntilBlock = tr.vub
 
-		if err := tr.acc.SignTx(netmode.PrivNet, &tx); err != nil {
-			log.Fatalf("Could not sign tx: %v", err)
+		for _, acc := range accs {
+			if err := acc.SignTx(netmode.PrivNet, tx); err != nil {
+				log.Fatalf("Could not sign tx: %v", err)
+			}
 		}
 
 		buf.Reset()
 		tx.EncodeBinary(buf.BinWriter)
 
 		if buf.Err != nil {
-			log.Fatalf("Could not prepare transaction: %d %v", i, buf.Err)
+			log.Fatalf("Could not prepare transaction: %d %v", tr.nonce, buf.Err)
 		}
 
 		out <- txBlob{
 			hash: tx.Hash().String(),
 			blob: base64.StdEncoding.EncodeToString(buf.Bytes()),
 		}
-
-		i++
 	}
 }
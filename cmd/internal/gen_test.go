@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nspcc-dev/neo-go/pkg/core/fee"
+	"github.com/nspcc-dev/neo-go/pkg/core/transaction"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+	"github.com/nspcc-dev/neo-go/pkg/io"
+	"github.com/nspcc-dev/neo-go/pkg/util"
+)
+
+func TestTransferNetworkFee(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	senders := NewSenders(first, 3, 1)
+
+	for _, scope := range []string{ScopeCalledByEntry, ScopeCustomContracts, ScopeCustomGroups, ScopeWitnessRules, ScopeGlobal} {
+		for cosigners := range 3 {
+			tx := newGASTransferTx(senders[0], util.Uint160{1}, senders[1:1+cosigners], scope)
+
+			// Fee of the transaction with actual witnesses.
+			var verification int64
+			for i, s := range senders[:1+cosigners] {
+				f, _ := fee.Calculate(defaultExecFeeFactor, s.PublicKey().GetVerificationScript())
+				verification += f
+				tx.Scripts = append(tx.Scripts, transaction.Witness{
+					InvocationScript:   append([]byte{0x0c, 64}, make([]byte, 64)...),
+					VerificationScript: senders[i].PublicKey().GetVerificationScript(),
+				})
+			}
+			need := verification + int64(io.GetVarSize(tx))*defaultFeePerByte
+			if tx.NetworkFee != need {
+				t.Errorf("%s scope, %d cosigners: expected fee %d, got %d", scope, cosigners, need, tx.NetworkFee)
+			}
+		}
+	}
+}
+
+func TestAccountPicker(t *testing.T) {
+	tests := []struct {
+		dist  string
+		n     int
+		shift int
+		check func(i, idx int) bool
+	}{
+		{DistRoundRobin, 5, 2, func(i, idx int) bool { return idx == (i+2)%5 }},
+		{DistUniform, 5, 0, func(_, idx int) bool { return idx >= 0 && idx < 5 }},
+		{DistZipf, 5, 0, func(_, idx int) bool { return idx >= 0 && idx < 5 }},
+		{DistFresh, 5, 0, func(i, idx int) bool { return idx == 5+i }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.dist, func(t *testing.T) {
+			pick := newAccountPicker(tc.dist, tc.n, tc.shift, rand.New(rand.NewPCG(1, seedStreamAccounts)))
+			for i := range 100 {
+				if idx := pick(i); !tc.check(i, idx) {
+					t.Fatalf("unexpected account %d for tx #%d", idx, i)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDistribution(t *testing.T) {
+	tests := []struct {
+		dist      string
+		receivers bool
+		ok        bool
+	}{
+		{DistRoundRobin, false, true},
+		{DistUniform, true, true},
+		{"ZIPF", false, true},
+		{DistFresh, true, true},
+		{DistFresh, false, false},
+		{"normal", true, false},
+	}
+	for _, tc := range tests {
+		if err := ValidateDistribution(tc.dist, tc.receivers); (err == nil) != tc.ok {
+			t.Errorf("%s (receivers %t): unexpected error %v", tc.dist, tc.receivers, err)
+		}
+	}
+}
+
+func TestReceiverAddress(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	senders := NewSenders(first, 2, 1)
+	if receiverAddress(senders, 1) != senders[1].GetScriptHash() {
+		t.Fatal("the first receivers should be senders")
+	}
+	seen := make(map[util.Uint160]bool)
+	for i := 2; i < 1000; i++ {
+		a := receiverAddress(senders, i)
+		if seen[a] {
+			t.Fatalf("duplicate receiver #%d", i)
+		}
+		seen[a] = true
+	}
+}
+
+func TestDumpNextContext(t *testing.T) {
+	// Generation is never started, so the dump waits for transactions.
+	dump := NewStreamDump(BenchOptions{TxCount: 10}, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*dumpPollTimeout)
+	defer cancel()
+
+	if _, ok := dump.next(ctx); ok {
+		t.Fatal("unexpected transaction")
+	}
+	if ctx.Err() == nil {
+		t.Fatal("next returned before the context is done")
+	}
+}
+
+func TestWriteDumpDeterministic(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	write := func(name string, seed uint64) []byte {
+		opts := BenchOptions{
+			TransferType:          GASTransfer,
+			TxCount:               100,
+			ToCount:               10,
+			Senders:               NewSenders(first, 5, seed),
+			SignersCount:          2,
+			WitnessScope:          ScopeCalledByEntry,
+			SendersDistribution:   DistUniform,
+			ReceiversDistribution: DistZipf,
+			Seed:                  seed,
+		}
+		path := filepath.Join(dir, name)
+		WriteDump(context.Background(), path, opts)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	a, b := write("a.txs", 42), write("b.txs", 42)
+	if !bytes.Equal(a, b) {
+		t.Fatal("dumps generated with the same seed differ")
+	}
+	if bytes.Equal(a, write("c.txs", 43)) {
+		t.Fatal("dumps generated with different seeds are the same")
+	}
+}
@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+	"github.com/nspcc-dev/neo-go/pkg/encoding/address"
+	"github.com/nspcc-dev/neo-go/pkg/smartcontract/scparser"
+	"github.com/nspcc-dev/neo-go/pkg/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// KeySource points to a private key given either as WIF or as an account
+	// of NEP-6 wallet.
+	KeySource struct {
+		WIF string `yaml:"wif,omitempty"`
+		// Wallet is a path to NEP-6 wallet, relative paths are resolved
+		// against keys config directory.
+		Wallet string `yaml:"wallet,omitempty"`
+		// Address of the wallet account, the first simple signature account
+		// is used if not set.
+		Address  string `yaml:"address,omitempty"`
+		Password string `yaml:"password,omitempty"`
+	}
+
+	// KeysConfig describes standby committee and the first tx sender keys.
+	KeysConfig struct {
+		// Committee is a list of standby committee keys in the order they're
+		// specified in the protocol configuration, the first ValidatorsCount
+		// of them are standby validators.
+		Committee []KeySource `yaml:"committee"`
+		Sender    KeySource   `yaml:"sender,omitempty"`
+	}
+
+	// Keys holds committee and the first tx sender private keys.
+	Keys struct {
+		// Committee is nil if default keys of the known networks should be used.
+		Committee []*keys.PrivateKey
+		Sender    *keys.PrivateKey
+	}
+)
+
+// LoadKeys reads keys config from the given path. Empty path means default keys
+// of 1, 4 and 7 nodes networks.
+func LoadKeys(path string) (*Keys, error) {
+	if path == "" {
+		sender, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+		if err != nil {
+			return nil, err
+		}
+		return &Keys{Sender: sender}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keys config: %w", err)
+	}
+
+	var cfg KeysConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("could not decode keys config: %w", err)
+	}
+
+	return cfg.Load(filepath.Dir(path))
+}
+
+// Load decrypts all keys of the config, wallet paths are resolved against the
+// given directory.
+func (c KeysConfig) Load(dir string) (*Keys, error) {
+	var (
+		res Keys
+		err error
+	)
+	for i := range c.Committee {
+		priv, err := c.Committee[i].Load(dir)
+		if err != nil {
+			return nil, fmt.Errorf("committee key #%d: %w", i, err)
+		}
+		res.Committee = append(res.Committee, priv)
+	}
+
+	if c.Sender == (KeySource{}) {
+		res.Sender, err = keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	} else {
+		res.Sender, err = c.Sender.Load(dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sender key: %w", err)
+	}
+
+	return &res, nil
+}
+
+// Load returns private key described by the source.
+func (s KeySource) Load(dir string) (*keys.PrivateKey, error) {
+	switch {
+	case s.WIF != "" && s.Wallet != "":
+		return nil, errors.New("either WIF or wallet should be specified")
+	case s.WIF != "":
+		return keys.NewPrivateKeyFromWIF(s.WIF)
+	case s.Wallet == "":
+		return nil, errors.New("neither WIF nor wallet is specified")
+	}
+
+	path := s.Wallet
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	w, err := wallet.NewWalletFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open wallet: %w", err)
+	}
+	defer w.Close()
+
+	var acc *wallet.Account
+	if s.Address != "" {
+		h, err := address.StringToUint160(s.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s: %w", s.Address, err)
+		}
+		acc = w.GetAccount(h)
+	} else {
+		for _, a := range w.Accounts {
+			if a.Contract != nil && scparser.IsSignatureContract(a.Contract.Script) {
+				acc = a
+				break
+			}
+		}
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("no suitable account in wallet %s", path)
+	}
+
+	if err := acc.Decrypt(s.Password, w.Scrypt); err != nil {
+		return nil, fmt.Errorf("could not decrypt account %s: %w", acc.Address, err)
+	}
+	// Copy the key since closing the wallet wipes decrypted keys.
+	return keys.NewPrivateKeyFromBytes(acc.PrivateKey().Bytes())
+}
+
+// KeysConfigName returns the name of keys config of the network of the given
+// size generated by cmd/config in .docker/ir.
+func KeysConfigName(validators int) string {
+	return "keys." + strconv.Itoa(validators) + ".yml"
+}
+
+// HasDefaultCommittee checks whether standby committee keys of the network of
+// the given size are known, so that keys config is not required.
+func HasDefaultCommittee(validators int) bool {
+	_, err := defaultCommittee(validators)
+	return err == nil
+}
+
+// defaultCommittee returns keys of the standby validators of known networks.
+func defaultCommittee(validatorCount int) ([]*keys.PrivateKey, error) {
+	var wifs []string
+	switch validatorCount {
+	case 1:
+		wifs = []string{"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY"}
+	case 4:
+		wifs = []string{
+			"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
+			"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
+			"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
+			"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+		}
+	case 7:
+		wifs = []string{
+			"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
+			"L392JMYfi7EUG3mjokQXnbVKJw1MdF42ZHe68xe5FPUEykHew7bS",
+			"L3suCMDA85Wwprk7fRqBV75ddQ3KSDh1CbapD6SXDGV6bMWnvBBK",
+			"L1SobH6JpM68XJRHWgQVM8X858zFRPHHpVULr95yAdHo4CBsb5Zu",
+			"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
+			"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
+			"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+		}
+	default:
+		return nil, fmt.Errorf("no default keys for %d validators, specify keys config", validatorCount)
+	}
+
+	privs := make([]*keys.PrivateKey, len(wifs))
+	for i := range wifs {
+		var err error
+		privs[i], err = keys.NewPrivateKeyFromWIF(wifs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return privs, nil
+}
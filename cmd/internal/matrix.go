@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type (
+	// MatrixCell is a single benchmark run of the matrix.
+	MatrixCell struct {
+		Mode    BenchMode
+		Workers int
+		// Rate is zero in worker mode.
+		Rate int
+	}
+
+	// MatrixReport is a combined summary of matrix runs.
+	MatrixReport struct {
+		rows []matrixRow
+	}
+
+	matrixRow struct {
+		cell   MatrixCell
+		report string
+		sum    ResultSummary
+	}
+)
+
+// NewMatrix returns cells of all combinations of modes, workers counts and
+// rates. Rates don't matter in worker mode, so such cells are not repeated.
+func NewMatrix(modes []BenchMode, workers, rates []int) []MatrixCell {
+	var cells []MatrixCell
+	for _, m := range modes {
+		for _, w := range workers {
+			if m != ModeRate {
+				cells = append(cells, MatrixCell{Mode: m, Workers: w})
+				continue
+			}
+			for _, r := range rates {
+				cells = append(cells, MatrixCell{Mode: m, Workers: w, Rate: r})
+			}
+		}
+	}
+	return cells
+}
+
+// String returns cell name in the same format runner.sh uses for reports,
+// e.g. rate_1000_workers_30 or wrk_30.
+func (c MatrixCell) String() string {
+	if c.Mode == ModeRate {
+		return fmt.Sprintf("%s_%d_workers_%d", c.Mode, c.Rate, c.Workers)
+	}
+	return fmt.Sprintf("%s_%d", c.Mode, c.Workers)
+}
+
+// ReportPath returns path of the cell report derived from the combined report
+// path, e.g. report_rate_1000_workers_30.log for report.log.
+func (c MatrixCell) ReportPath(out string) string {
+	ext := filepath.Ext(out)
+	return strings.TrimSuffix(out, ext) + "_" + c.String() + ext
+}
+
+// Add accounts results of the cell stored in the given report.
+func (m *MatrixReport) Add(cell MatrixCell, report string, sum ResultSummary) {
+	m.rows = append(m.rows, matrixRow{cell: cell, report: report, sum: sum})
+}
+
+// WriteTo writes combined summary to io.Writer.
+func (m *MatrixReport) WriteTo(rw io.Writer) (int64, error) {
+	var (
+		out = io.MultiWriter(rw, os.Stdout)
+		cnt int64
+	)
+
+	num, err := fmt.Fprintf(out, "Matrix of %d runs\n\nMode, Workers, Rate, TXs, RPS, ErrRate, TPS, CPU, Mem, Report\n", len(m.rows))
+	cnt += int64(num)
+	if err != nil {
+		return cnt, err
+	}
+
+	for _, r := range m.rows {
+		num, err = fmt.Fprintf(out, "%s, %d, %d, %d, %0.3f, %0.3f%%, %0.3f, %0.3f%%, %0.3fMB, %s\n",
+			r.cell.Mode, r.cell.Workers, r.cell.Rate, r.sum.TxCount, r.sum.RPS, r.sum.ErrRate, r.sum.TPS,
+			r.sum.CPU, r.sum.Mem, filepath.Base(r.report))
+		cnt += int64(num)
+		if err != nil {
+			return cnt, err
+		}
+	}
+
+	return cnt, nil
+}
+
+// WaitSettled waits until the node mempool is drained and the next block is
+// accepted after that, so that the following run starts from the idle chain.
+func WaitSettled(ctx context.Context, cli *RPCClient, msPerBlock int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	period := max(time.Duration(msPerBlock)*time.Millisecond/4, 100*time.Millisecond)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	drained := -1
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("chain hasn't settled in %s: %w", timeout, ctx.Err())
+		case <-ticker.C:
+		}
+
+		height, err := cli.GetBlockCount(ctx)
+		if err != nil {
+			log.Printf("could not fetch block count: %v", err)
+			continue
+		}
+
+		if drained >= 0 {
+			if height > drained {
+				log.Printf("Chain settled at block %d", height-1)
+				return nil
+			}
+			continue
+		}
+
+		count, err := cli.GetMempoolCount(ctx)
+		if err != nil {
+			log.Printf("could not fetch mempool: %v", err)
+			continue
+		}
+		if count == 0 {
+			drained = height
+		}
+	}
+}
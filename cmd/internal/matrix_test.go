@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		modes   []BenchMode
+		workers []int
+		rates   []int
+		want    []string
+	}{
+		{"worker", []BenchMode{ModeWorker}, []int{10, 30}, []int{100, 1000},
+			[]string{"wrk_10", "wrk_30"}},
+		{"rate", []BenchMode{ModeRate}, []int{30}, []int{100, 1000},
+			[]string{"rate_100_workers_30", "rate_1000_workers_30"}},
+		{"both", []BenchMode{ModeRate, ModeWorker}, []int{10, 30}, []int{100},
+			[]string{"rate_100_workers_10", "rate_100_workers_30", "wrk_10", "wrk_30"}},
+		{"no rates", []BenchMode{ModeRate}, []int{30}, nil, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, c := range NewMatrix(tc.modes, tc.workers, tc.rates) {
+				got = append(got, c.String())
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMatrixCellReportPath(t *testing.T) {
+	c := MatrixCell{Mode: ModeRate, Workers: 30, Rate: 1000}
+	if p := c.ReportPath("/out/report.log"); p != "/out/report_rate_1000_workers_30.log" {
+		t.Fatalf("unexpected path %s", p)
+	}
+}
@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+type (
+	metricsParams struct {
+		enableLogger bool
+		endpoints    []string
+		names        []string
+		period       time.Duration
+		timeout      time.Duration
+	}
+
+	// MetricsScraper interface.
+	MetricsScraper interface {
+		Run(ctx context.Context, cb MetricsCallback)
+		Scrape(ctx context.Context, endpoint string) (map[string]float64, error)
+	}
+
+	metricsScraper struct {
+		*log.Logger
+
+		per       time.Duration
+		cli       *http.Client
+		endpoints []string
+		names     []string
+	}
+
+	// MetricsOption is an option type to configure metrics scraper.
+	MetricsOption func(*metricsParams)
+
+	// MetricsCallback used to report current values of series scraped from
+	// the endpoint.
+	MetricsCallback func(endpoint string, values map[string]float64)
+)
+
+// DefaultMetrics are the node metrics scraped if no names are specified.
+var DefaultMetrics = []string{
+	"neogo_current_block_height",
+	"neogo_mempool_unsorted_tx",
+	"neogo_peers_connected",
+}
+
+// MetricsEndpoints sets Prometheus endpoints to scrape, endpoint without scheme
+// and path is treated as http://<endpoint>/metrics.
+func MetricsEndpoints(endpoints []string) MetricsOption {
+	return func(p *metricsParams) {
+		p.endpoints = endpoints
+	}
+}
+
+// MetricsNames sets names of metrics to record, name ending with '*' matches
+// all metrics with the given prefix.
+func MetricsNames(names []string) MetricsOption {
+	return func(p *metricsParams) {
+		p.names = names
+	}
+}
+
+// MetricsPeriod sets period for metrics scraping.
+func MetricsPeriod(dur time.Duration) MetricsOption {
+	return func(p *metricsParams) {
+		p.period = dur
+	}
+}
+
+// MetricsEnableLogger enables logs.
+func MetricsEnableLogger() MetricsOption {
+	return func(p *metricsParams) {
+		p.enableLogger = true
+	}
+}
+
+// NewMetricsScraper creates MetricsScraper, to fetch node metrics from
+// Prometheus endpoints.
+func NewMetricsScraper(ctx context.Context, opts ...MetricsOption) (MetricsScraper, error) {
+	p := &metricsParams{
+		names:   DefaultMetrics,
+		period:  time.Second,
+		timeout: DefaultTimeout,
+	}
+
+	for i := range opts {
+		opts[i](p)
+	}
+
+	if len(p.endpoints) == 0 {
+		return nil, errors.New("no metrics endpoints")
+	}
+
+	ms := &metricsScraper{
+		per:    p.period,
+		cli:    &http.Client{Timeout: p.timeout},
+		names:  p.names,
+		Logger: log.New(os.Stdout, "", log.LstdFlags),
+	}
+
+	for _, e := range p.endpoints {
+		if !strings.Contains(e, "://") {
+			e = "http://" + e + "/metrics"
+		}
+		ms.endpoints = append(ms.endpoints, e)
+	}
+
+	if !p.enableLogger {
+		ms.SetOutput(io.Discard)
+	}
+
+	for _, e := range ms.endpoints {
+		if _, err := ms.Scrape(ctx, e); err != nil {
+			return nil, err
+		}
+	}
+
+	return ms, nil
+}
+
+// Scrape returns current values of selected series exposed by the endpoint.
+// Series are keyed by the metric name with labels, histograms and summaries
+// are represented by _sum and _count series.
+func (s *metricsScraper) Scrape(ctx context.Context, endpoint string) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", endpoint, resp.Status)
+	}
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", endpoint, err)
+	}
+
+	values := make(map[string]float64)
+	for name, mf := range families {
+		if !s.selected(name) {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			key := name + seriesLabels(m.GetLabel())
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				values[key] = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				values[key] = m.GetGauge().GetValue()
+			case dto.MetricType_HISTOGRAM:
+				values[name+"_sum"+seriesLabels(m.GetLabel())] = m.GetHistogram().GetSampleSum()
+				values[name+"_count"+seriesLabels(m.GetLabel())] = float64(m.GetHistogram().GetSampleCount())
+			case dto.MetricType_SUMMARY:
+				values[name+"_sum"+seriesLabels(m.GetLabel())] = m.GetSummary().GetSampleSum()
+				values[name+"_count"+seriesLabels(m.GetLabel())] = float64(m.GetSummary().GetSampleCount())
+			default:
+				values[key] = m.GetUntyped().GetValue()
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// Run worker to periodically scraping metrics from all endpoints.
+func (s *metricsScraper) Run(ctx context.Context, cb MetricsCallback) {
+	done := ctx.Done()
+	tick := time.NewTimer(s.per)
+
+loop:
+	for {
+		select {
+		case <-done:
+			break loop
+		case <-tick.C:
+			for _, e := range s.endpoints {
+				values, err := s.Scrape(ctx, e)
+				if errors.Is(err, context.Canceled) {
+					break loop
+				} else if err != nil {
+					s.Printf("Could not scrape metrics: %v", err)
+					continue
+				}
+
+				cb(e, values)
+			}
+
+			tick.Reset(s.per)
+		}
+	}
+}
+
+func (s *metricsScraper) selected(name string) bool {
+	for _, n := range s.names {
+		if prefix, ok := strings.CutSuffix(n, "*"); ok && strings.HasPrefix(name, prefix) || n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func seriesLabels(labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels))
+	for _, l := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
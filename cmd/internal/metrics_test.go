@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testExposition = `# HELP neogo_current_block_height Current index of processed block
+# TYPE neogo_current_block_height gauge
+neogo_current_block_height 42
+# HELP neogo_mempool_unsorted_tx Mempool unsorted transactions
+# TYPE neogo_mempool_unsorted_tx gauge
+neogo_mempool_unsorted_tx 7
+# HELP neogo_rpc_requests_total RPC requests
+# TYPE neogo_rpc_requests_total counter
+neogo_rpc_requests_total{method="sendrawtransaction",status="ok"} 1000
+neogo_rpc_requests_total{method="getblockcount",status="ok"} 10
+# HELP neogo_block_time Block processing time
+# TYPE neogo_block_time histogram
+neogo_block_time_bucket{le="0.5"} 3
+neogo_block_time_bucket{le="+Inf"} 4
+neogo_block_time_sum 1.5
+neogo_block_time_count 4
+# HELP go_goroutines Number of goroutines
+# TYPE go_goroutines gauge
+go_goroutines 100
+`
+
+func newMetricsServer(t *testing.T, status int, body string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMetricsScraper(t *testing.T) {
+	srv := newMetricsServer(t, http.StatusOK, testExposition)
+	endpoint := strings.TrimPrefix(srv.URL, "http://")
+
+	tests := []struct {
+		name  string
+		names []string
+		want  map[string]float64
+	}{
+		{"default", nil, map[string]float64{
+			"neogo_current_block_height": 42,
+			"neogo_mempool_unsorted_tx":  7,
+		}},
+		{"prefix", []string{"neogo_rpc_*", "neogo_block_time"}, map[string]float64{
+			`neogo_rpc_requests_total{method="getblockcount",status="ok"}`:      10,
+			`neogo_rpc_requests_total{method="sendrawtransaction",status="ok"}`: 1000,
+			"neogo_block_time_sum":   1.5,
+			"neogo_block_time_count": 4,
+		}},
+		{"missing", []string{"neogo_unknown"}, map[string]float64{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []MetricsOption{MetricsEndpoints([]string{endpoint})}
+			if tc.names != nil {
+				opts = append(opts, MetricsNames(tc.names))
+			}
+			ms, err := NewMetricsScraper(context.Background(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, err := ms.Scrape(context.Background(), srv.URL+"/metrics")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(values, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, values)
+			}
+		})
+	}
+}
+
+func TestMetricsScraperRun(t *testing.T) {
+	srv := newMetricsServer(t, http.StatusOK, testExposition)
+
+	ms, err := NewMetricsScraper(context.Background(),
+		MetricsEndpoints([]string{srv.URL + "/metrics"}), MetricsPeriod(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan string, 1)
+	go ms.Run(ctx, func(endpoint string, values map[string]float64) {
+		if values["neogo_current_block_height"] == 42 {
+			select {
+			case got <- endpoint:
+			default:
+			}
+		}
+	})
+
+	select {
+	case e := <-got:
+		if e != srv.URL+"/metrics" {
+			t.Fatalf("unexpected endpoint %s", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("metrics aren't reported")
+	}
+}
+
+func TestMetricsScraperErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		err    string
+	}{
+		{"status", http.StatusServiceUnavailable, "", "unexpected status 503"},
+		{"format", http.StatusOK, "neogo_current_block_height forty-two\n", "text format parsing error"},
+	}
+	for _, tc := range tests {
+		srv := newMetricsServer(t, tc.status, tc.body)
+		_, err := NewMetricsScraper(context.Background(), MetricsEndpoints([]string{strings.TrimPrefix(srv.URL, "http://")}))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+	}
+
+	if _, err := NewMetricsScraper(context.Background()); err == nil {
+		t.Error("expected error without endpoints")
+	}
+}
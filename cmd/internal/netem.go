@@ -0,0 +1,385 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type (
+	// NetProfile describes network conditions emulated with 'tc qdisc netem'
+	// on the node egress traffic.
+	NetProfile struct {
+		Delay  time.Duration `yaml:"delay"`
+		Jitter time.Duration `yaml:"jitter"`
+		// Loss is a percentage of packets dropped.
+		Loss float64 `yaml:"loss"`
+		// Rate is a bandwidth limit in tc units, e.g. 10mbit.
+		Rate string `yaml:"rate"`
+	}
+
+	// NetLink is a profile of the traffic sent from one node to another, it
+	// replaces the sender node profile for this destination.
+	NetLink struct {
+		From       string `yaml:"from"`
+		To         string `yaml:"to"`
+		NetProfile `yaml:",inline"`
+	}
+
+	// NetStage is a network state set at the given offset from the moment
+	// the bench starts sending transactions, nodes and links not mentioned
+	// have no emulation applied.
+	NetStage struct {
+		At    time.Duration         `yaml:"at"`
+		Nodes map[string]NetProfile `yaml:"nodes"`
+		Links []NetLink             `yaml:"links"`
+	}
+
+	// NetRecord is a profile applied to the node or link during the run.
+	NetRecord struct {
+		// At is an offset from the report start.
+		At   time.Duration
+		From string
+		// To is empty for node profile.
+		To      string
+		Profile NetProfile
+		Err     error
+	}
+)
+
+const (
+	// NetDevice is a network interface of the node containers.
+	NetDevice = "eth0"
+	// BenchStartLog is logged by the bench when it starts sending
+	// transactions followed by the report start time in Unix milliseconds,
+	// the report offsets are counted from it.
+	BenchStartLog = "Report start time (unix ms) ="
+)
+
+// Args returns 'tc qdisc netem' arguments of the profile.
+func (p NetProfile) Args() []string {
+	var args []string
+	if p.Delay != 0 {
+		args = append(args, "delay", tcTime(p.Delay))
+		if p.Jitter != 0 {
+			args = append(args, tcTime(p.Jitter))
+		}
+	}
+	if p.Loss != 0 {
+		args = append(args, "loss", strconv.FormatFloat(p.Loss, 'f', -1, 64)+"%")
+	}
+	if p.Rate != "" {
+		args = append(args, "rate", p.Rate)
+	}
+	return args
+}
+
+// String returns netem arguments of the profile or "none" for empty one.
+func (p NetProfile) String() string {
+	if p == (NetProfile{}) {
+		return "none"
+	}
+	return strings.Join(p.Args(), " ")
+}
+
+// rateRe matches tc rate values, they're passed to the shell inside the
+// container, so nothing else is allowed.
+var rateRe = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([kmgt]i?)?(bit|bps)$`)
+
+// Validate checks profile values.
+func (p NetProfile) Validate() error {
+	var errs []error
+	if p.Delay < 0 || p.Jitter < 0 {
+		errs = append(errs, errors.New("negative delay"))
+	}
+	if p.Jitter != 0 && p.Delay == 0 {
+		errs = append(errs, errors.New("jitter requires delay"))
+	}
+	if p.Loss < 0 || p.Loss > 100 {
+		errs = append(errs, fmt.Errorf("invalid loss percentage %v", p.Loss))
+	}
+	if p.Rate != "" && !rateRe.MatchString(p.Rate) {
+		errs = append(errs, fmt.Errorf("invalid rate %q", p.Rate))
+	}
+	return errors.Join(errs...)
+}
+
+// validateNetwork checks network stages against the given node names.
+func validateNetwork(stages []NetStage, names []string) error {
+	var (
+		errs  []error
+		known = func(name string) bool { return slices.Contains(names, name) }
+	)
+	for i, s := range stages {
+		if i > 0 && s.At <= stages[i-1].At {
+			errs = append(errs, fmt.Errorf("network stage #%d: stages should be ordered by time", i))
+		}
+		if s.At < 0 {
+			errs = append(errs, fmt.Errorf("network stage #%d: negative offset", i))
+		}
+		for name, p := range s.Nodes {
+			if !known(name) {
+				errs = append(errs, fmt.Errorf("network stage #%d: unknown node %s", i, name))
+			}
+			if err := p.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("network stage #%d: node %s: %w", i, name, err))
+			}
+		}
+		seen := make(map[[2]string]bool)
+		for _, l := range s.Links {
+			if !known(l.From) || !known(l.To) || l.From == l.To {
+				errs = append(errs, fmt.Errorf("network stage #%d: invalid link %s -> %s", i, l.From, l.To))
+			}
+			if seen[[2]string{l.From, l.To}] {
+				errs = append(errs, fmt.Errorf("network stage #%d: duplicate link %s -> %s", i, l.From, l.To))
+			}
+			seen[[2]string{l.From, l.To}] = true
+			if err := l.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("network stage #%d: link %s -> %s: %w", i, l.From, l.To, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// tcScript returns shell script setting the node egress qdisc up: the node
+// profile is applied to the default HTB class, every link gets a class of its
+// own selected by the destination address. ips are the addresses of link
+// destinations in the links order.
+func tcScript(node NetProfile, links []NetLink, ips []string) string {
+	var (
+		b   strings.Builder
+		dev = " dev " + NetDevice + " "
+	)
+	b.WriteString("tc qdisc del" + dev + "root 2>/dev/null; ")
+	if node == (NetProfile{}) && len(links) == 0 {
+		return b.String() + "true"
+	}
+
+	b.WriteString("set -e; ")
+	b.WriteString("tc qdisc add" + dev + "root handle 1: htb default 1; ")
+	b.WriteString("tc class add" + dev + "parent 1: classid 1:1 htb rate 10gbit; ")
+	if node != (NetProfile{}) {
+		fmt.Fprintf(&b, "tc qdisc add%sparent 1:1 handle 10: netem %s; ", dev, strings.Join(node.Args(), " "))
+	}
+	for i, l := range links {
+		class := fmt.Sprintf("1:%x", 0x10+i)
+		fmt.Fprintf(&b, "tc class add%sparent 1: classid %s htb rate 10gbit; ", dev, class)
+		if l.NetProfile != (NetProfile{}) {
+			fmt.Fprintf(&b, "tc qdisc add%sparent %s handle %x: netem %s; ", dev, class, 0x100+i, strings.Join(l.Args(), " "))
+		}
+		fmt.Fprintf(&b, "tc filter add%sparent 1: protocol ip prio 1 u32 match ip dst %s/32 flowid %s; ", dev, ips[i], class)
+	}
+	return strings.TrimSuffix(b.String(), "; ")
+}
+
+// WriteNetRecords writes profiles applied during the run as a report section.
+func WriteNetRecords(w io.Writer, records []NetRecord) error {
+	if _, err := fmt.Fprintf(w, "\nNetwork profile\n\nAt, From, To, Profile, Error\n"); err != nil {
+		return err
+	}
+	for _, r := range records {
+		to, errStr := r.To, ""
+		if to == "" {
+			to = "*"
+		}
+		if r.Err != nil {
+			errStr = strings.ReplaceAll(r.Err.Error(), "\n", " ")
+		}
+		if _, err := fmt.Fprintf(w, "%s, %s, %s, %s, %s\n", r.At, r.From, to, r.Profile, errStr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runNetStages waits for the bench to start sending transactions, applies
+// network stages of the topology at their offsets from that moment until the
+// context is done and returns the applied profiles. Records are timed from
+// the report start received from the bench, like the other report sections.
+func (c *Cluster) runNetStages(ctx context.Context, started <-chan time.Time) []NetRecord {
+	var (
+		records     []NetRecord
+		reportStart time.Time
+	)
+	select {
+	case <-ctx.Done():
+		return nil
+	case reportStart = <-started:
+	}
+
+	start := time.Now()
+	for _, s := range c.topo.Network {
+		timer := time.NewTimer(time.Until(start.Add(s.At)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return records
+		case <-timer.C:
+		}
+		c.Printf("Apply network stage at %s", s.At)
+		records = append(records, c.applyNetStage(ctx, s, time.Since(reportStart).Round(time.Millisecond))...)
+	}
+	return records
+}
+
+// resetNetwork removes emulation applied by network stages from all nodes.
+func (c *Cluster) resetNetwork(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultTimeout)
+	defer cancel()
+
+	c.Printf("Reset network profiles")
+	c.applyNetStage(ctx, NetStage{}, 0)
+}
+
+// benchStartWatcher passes the output through looking for BenchStartLog line
+// to send the report start time to the channel once.
+type benchStartWatcher struct {
+	w    io.Writer
+	buf  []byte
+	once *sync.Once
+	ch   chan<- time.Time
+}
+
+func (b *benchStartWatcher) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	for {
+		i := bytes.IndexByte(b.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(b.buf[:i])
+		b.buf = b.buf[i+1:]
+
+		_, ms, ok := strings.Cut(line, BenchStartLog)
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseInt(strings.TrimSpace(ms), 10, 64); err == nil {
+			b.once.Do(func() { b.ch <- time.UnixMilli(v) })
+		}
+	}
+	return b.w.Write(p)
+}
+
+// applyNetStage replaces egress qdisc of every node with the one of the stage,
+// records are marked with the given offset.
+func (c *Cluster) applyNetStage(ctx context.Context, s NetStage, at time.Duration) []NetRecord {
+	var records []NetRecord
+	for _, name := range c.topo.NodeNames() {
+		var (
+			links []NetLink
+			ips   []string
+			err   error
+		)
+		for _, l := range s.Links {
+			if l.From != name {
+				continue
+			}
+			var ip string
+			ip, err = c.nodeIP(ctx, l.To)
+			if err != nil {
+				break
+			}
+			links = append(links, l)
+			ips = append(ips, ip)
+		}
+		if err == nil {
+			err = c.exec(ctx, c.nodeIDs[name], "sh", "-c", tcScript(s.Nodes[name], links, ips))
+		}
+		if err != nil {
+			c.Printf("Could not apply network profile of %s: %v", name, err)
+		}
+
+		records = append(records, NetRecord{At: at, From: name, Profile: s.Nodes[name], Err: err})
+		for _, l := range s.Links {
+			if l.From == name {
+				records = append(records, NetRecord{At: at, From: name, To: l.To, Profile: l.NetProfile, Err: err})
+			}
+		}
+	}
+	return records
+}
+
+// nodeIP returns address of the node in the cluster network.
+func (c *Cluster) nodeIP(ctx context.Context, name string) (string, error) {
+	info, err := c.cli.ContainerInspect(ctx, c.nodeIDs[name])
+	if err != nil {
+		return "", fmt.Errorf("could not inspect %s: %w", name, err)
+	}
+	if info.NetworkSettings == nil || info.NetworkSettings.Networks[ClusterNetwork] == nil {
+		return "", fmt.Errorf("%s is not connected to %s", name, ClusterNetwork)
+	}
+	return info.NetworkSettings.Networks[ClusterNetwork].IPAddress, nil
+}
+
+// exec runs command in the container, non-zero exit code is returned as an
+// error along with the command output.
+func (c *Cluster) exec(ctx context.Context, id string, cmd ...string) error {
+	resp, err := c.cli.ContainerExecCreate(ctx, id, container.ExecOptions{Cmd: cmd, AttachStdout: true, AttachStderr: true})
+	if err != nil {
+		return fmt.Errorf("could not create exec: %w", err)
+	}
+	att, err := c.cli.ContainerExecAttach(ctx, resp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return fmt.Errorf("could not attach to exec: %w", err)
+	}
+	defer att.Close()
+
+	var out bytes.Buffer
+	if _, err := stdcopy.StdCopy(&out, &out, att.Reader); err != nil {
+		return fmt.Errorf("could not read exec output: %w", err)
+	}
+	info, err := c.cli.ContainerExecInspect(ctx, resp.ID)
+	if err != nil {
+		return fmt.Errorf("could not inspect exec: %w", err)
+	}
+	if info.ExitCode != 0 {
+		return fmt.Errorf("%s exited with code %d: %s", cmd[0], info.ExitCode, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// writeNetRecords appends applied profiles to the bench report if it's
+// written to the reports directory, network.log is created there otherwise.
+func (c *Cluster) writeNetRecords(out string, args []string, records []NetRecord) {
+	file := filepath.Join(out, "network.log")
+	if report, ok := flagValue(args, "out", "o"); ok && strings.HasPrefix(path.Clean(report), "/out/") {
+		file = filepath.Join(out, strings.TrimPrefix(path.Clean(report), "/out/"))
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		c.Printf("Could not store network profile: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if err := WriteNetRecords(f, records); err != nil {
+		c.Printf("Could not store network profile: %v", err)
+		return
+	}
+	c.Printf("Network profile is stored in %s", file)
+}
+
+// tcTime formats duration in tc units.
+func tcTime(d time.Duration) string {
+	if d%time.Millisecond == 0 {
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+	return strconv.FormatInt(d.Microseconds(), 10) + "us"
+}
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNetProfileValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    NetProfile
+		ok   bool
+	}{
+		{"empty", NetProfile{}, true},
+		{"full", NetProfile{Delay: 50 * time.Millisecond, Jitter: time.Millisecond, Loss: 1.5, Rate: "10mbit"}, true},
+		{"negative delay", NetProfile{Delay: -time.Millisecond}, false},
+		{"jitter without delay", NetProfile{Jitter: time.Millisecond}, false},
+		{"loss", NetProfile{Loss: 101}, false},
+		{"rate units", NetProfile{Rate: "1.5kibps"}, true},
+		{"rate without unit", NetProfile{Rate: "100"}, false},
+		{"rate injection", NetProfile{Rate: "1mbit; reboot"}, false},
+		{"rate newline", NetProfile{Rate: "1mbit\nreboot"}, false},
+		{"rate and", NetProfile{Rate: "1mbit&&x"}, false},
+		{"rate pipe", NetProfile{Rate: "1mbit|x"}, false},
+		{"rate redirect", NetProfile{Rate: "1mbit>/etc/x"}, false},
+		{"rate subshell", NetProfile{Rate: "$(x)mbit"}, false},
+	}
+	for _, tc := range tests {
+		if err := tc.p.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+	}
+}
+
+func TestNetProfileArgs(t *testing.T) {
+	tests := []struct {
+		p    NetProfile
+		want string
+	}{
+		{NetProfile{}, "none"},
+		{NetProfile{Delay: 50 * time.Millisecond, Jitter: 500 * time.Microsecond}, "delay 50ms 500us"},
+		{NetProfile{Loss: 0.5, Rate: "1mbit"}, "loss 0.5% rate 1mbit"},
+	}
+	for _, tc := range tests {
+		if s := tc.p.String(); s != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, s)
+		}
+	}
+}
+
+func TestTCScript(t *testing.T) {
+	const reset = "tc qdisc del dev eth0 root 2>/dev/null; "
+	tests := []struct {
+		name  string
+		node  NetProfile
+		links []NetLink
+		want  []string
+	}{
+		{"reset", NetProfile{}, nil, []string{reset + "true"}},
+		{"node", NetProfile{Delay: 10 * time.Millisecond}, nil, []string{
+			reset + "set -e; ",
+			"tc qdisc add dev eth0 root handle 1: htb default 1; ",
+			"tc class add dev eth0 parent 1: classid 1:1 htb rate 10gbit; ",
+			"tc qdisc add dev eth0 parent 1:1 handle 10: netem delay 10ms",
+		}},
+		{"links", NetProfile{}, []NetLink{
+			{From: "one", To: "two", NetProfile: NetProfile{Loss: 1}},
+			{From: "one", To: "three"},
+		}, []string{
+			reset + "set -e; ",
+			"tc qdisc add dev eth0 root handle 1: htb default 1; ",
+			"tc class add dev eth0 parent 1: classid 1:1 htb rate 10gbit; ",
+			"tc class add dev eth0 parent 1: classid 1:10 htb rate 10gbit; ",
+			"tc qdisc add dev eth0 parent 1:10 handle 100: netem loss 1%; ",
+			"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst 172.200.0.2/32 flowid 1:10; ",
+			"tc class add dev eth0 parent 1: classid 1:11 htb rate 10gbit; ",
+			"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst 172.200.0.3/32 flowid 1:11",
+		}},
+	}
+	for _, tc := range tests {
+		ips := []string{"172.200.0.2", "172.200.0.3"}[:len(tc.links)]
+		if s := tcScript(tc.node, tc.links, ips); s != strings.Join(tc.want, "") {
+			t.Errorf("%s: unexpected script:\n%s", tc.name, s)
+		}
+	}
+}
+
+func TestValidateNetwork(t *testing.T) {
+	names := []string{"one", "two", "go-node"}
+	tests := []struct {
+		name   string
+		stages []NetStage
+		ok     bool
+	}{
+		{"valid", []NetStage{
+			{Nodes: map[string]NetProfile{"one": {Delay: time.Millisecond}}},
+			{At: time.Minute, Links: []NetLink{{From: "one", To: "go-node"}}},
+		}, true},
+		{"order", []NetStage{{At: time.Minute}, {At: time.Second}}, false},
+		{"unknown node", []NetStage{{Nodes: map[string]NetProfile{"three": {}}}}, false},
+		{"self link", []NetStage{{Links: []NetLink{{From: "one", To: "one"}}}}, false},
+		{"duplicate link", []NetStage{{Links: []NetLink{{From: "one", To: "two"}, {From: "one", To: "two"}}}}, false},
+		{"invalid profile", []NetStage{{Nodes: map[string]NetProfile{"one": {Loss: -1}}}}, false},
+	}
+	for _, tc := range tests {
+		if err := validateNetwork(tc.stages, names); (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+	}
+}
+
+func TestBenchStartWatcher(t *testing.T) {
+	var (
+		out bytes.Buffer
+		ch  = make(chan time.Time, 1)
+		w   = &benchStartWatcher{w: &out, once: new(sync.Once), ch: ch}
+	)
+	input := "2024/04/22 17:03:43 Started test from block = 17\n2024/04/22 17:03:43 " + BenchStartLog + " 1713805423759\n"
+	// Lines may be split between writes.
+	for _, part := range []string{input[:60], input[60:90], input[90:], input} {
+		if _, err := w.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.String() != input+input {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	select {
+	case start := <-ch:
+		if start.UnixMilli() != 1713805423759 {
+			t.Fatalf("unexpected start %v", start)
+		}
+	default:
+		t.Fatal("start isn't signalled")
+	}
+	if len(ch) != 0 {
+		t.Fatal("start is signalled twice")
+	}
+}
@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
 	"github.com/nspcc-dev/neo-go/pkg/io"
 )
@@ -11,9 +14,65 @@ type BenchOptions struct {
 	TxCount      uint64
 	ToCount      int
 	Senders      []*keys.PrivateKey
+	// SignersCount is the number of tx signers, the first one is always the
+	// sender, the rest are taken from the following Senders.
+	SignersCount int
+	// WitnessScope is the witness scope used for every tx signer.
+	WitnessScope string
+	// SendersDistribution defines how senders are picked for txs.
+	SendersDistribution string
+	// ReceiversDistribution defines how receivers are picked for txs.
+	ReceiversDistribution string
+	// Seed is used to derive senders, receivers and nonces, so that the same
+	// options produce the same transactions.
+	Seed uint64
+}
+
+// Validate checks whether transactions can be generated with the options.
+func (o *BenchOptions) Validate() error {
+	switch strings.ToLower(o.TransferType) {
+	case NEOTransfer, GASTransfer, ContractTransfer:
+	default:
+		return fmt.Errorf("invalid type: %s", o.TransferType)
+	}
+	if o.SignersCount < 1 || o.SignersCount > len(o.Senders) {
+		return fmt.Errorf("signers count should be in [1, %d] range, got %d", len(o.Senders), o.SignersCount)
+	}
+	if strings.ToLower(o.TransferType) == ContractTransfer && o.Senders[0].Address() != firstSenderAddress() {
+		// The token contract mints all funds to the owner hardcoded into it.
+		return fmt.Errorf("%s transfers require the default first sender %s, got %s",
+			ContractTransfer, firstSenderAddress(), o.Senders[0].Address())
+	}
+	if err := ValidateWitnessScope(o.WitnessScope); err != nil {
+		return err
+	}
+	if err := ValidateDistribution(o.SendersDistribution, false); err != nil {
+		return err
+	}
+	return ValidateDistribution(o.ReceiversDistribution, true)
+}
+
+// firstSenderAddress returns the address of FirstSenderWIF which is the owner
+// of the token contract.
+func firstSenderAddress() string {
+	priv, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		panic(err)
+	}
+	return priv.Address()
 }
 
+// Options are encoded with optionsMagic followed by the format version. Legacy
+// dumps start with the transfer type length which is always less than the
+// magic, they only contain the type, senders, receivers and txs count.
+const (
+	optionsMagic   = 0xff
+	optionsVersion = 1
+)
+
 func (o *BenchOptions) EncodeBinary(w *io.BinWriter) {
+	w.WriteB(optionsMagic)
+	w.WriteB(optionsVersion)
 	w.WriteString(o.TransferType)
 	w.WriteVarUint(uint64(len(o.Senders)))
 	for _, p := range o.Senders {
@@ -21,10 +80,33 @@ func (o *BenchOptions) EncodeBinary(w *io.BinWriter) {
 	}
 	w.WriteVarUint(uint64(o.ToCount))
 	w.WriteU64LE(uint64(o.TxCount))
+	w.WriteVarUint(uint64(o.SignersCount))
+	w.WriteString(o.WitnessScope)
+	w.WriteString(o.SendersDistribution)
+	w.WriteString(o.ReceiversDistribution)
+	w.WriteU64LE(o.Seed)
 }
 
 func (o *BenchOptions) DecodeBinary(r *io.BinReader) {
-	o.TransferType = r.ReadString()
+	legacy := false
+	switch b := r.ReadB(); {
+	case r.Err != nil:
+		return
+	case b == optionsMagic:
+		if v := r.ReadB(); r.Err == nil && v != optionsVersion {
+			r.Err = fmt.Errorf("unsupported dump options version %d", v)
+			return
+		}
+		o.TransferType = r.ReadString()
+	case b < 0xfd:
+		legacy = true
+		buf := make([]byte, b)
+		r.ReadBytes(buf)
+		o.TransferType = string(buf)
+	default:
+		r.Err = fmt.Errorf("invalid dump options prefix %#x", b)
+		return
+	}
 	privCount := int(r.ReadVarUint())
 	if r.Err != nil {
 		return
@@ -44,4 +126,16 @@ func (o *BenchOptions) DecodeBinary(r *io.BinReader) {
 
 	o.ToCount = int(r.ReadVarUint())
 	o.TxCount = r.ReadU64LE()
+	if legacy {
+		o.SignersCount = 1
+		o.WitnessScope = ScopeCalledByEntry
+		o.SendersDistribution = DistRoundRobin
+		o.ReceiversDistribution = DistRoundRobin
+		return
+	}
+	o.SignersCount = int(r.ReadVarUint())
+	o.WitnessScope = r.ReadString()
+	o.SendersDistribution = r.ReadString()
+	o.ReceiversDistribution = r.ReadString()
+	o.Seed = r.ReadU64LE()
 }
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+	"github.com/nspcc-dev/neo-go/pkg/io"
+)
+
+func TestBenchOptionsEncoding(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := BenchOptions{
+		TransferType:          GASTransfer,
+		TxCount:               100,
+		ToCount:               10,
+		Senders:               NewSenders(first, 3, 42),
+		SignersCount:          2,
+		WitnessScope:          ScopeWitnessRules,
+		SendersDistribution:   DistZipf,
+		ReceiversDistribution: DistFresh,
+		Seed:                  42,
+	}
+
+	w := io.NewBufBinWriter()
+	opts.EncodeBinary(w.BinWriter)
+	if w.Err != nil {
+		t.Fatal(w.Err)
+	}
+
+	var res BenchOptions
+	r := io.NewBinReaderFromBuf(w.Bytes())
+	res.DecodeBinary(r)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if len(res.Senders) != len(opts.Senders) {
+		t.Fatalf("expected %d senders, got %d", len(opts.Senders), len(res.Senders))
+	}
+	for i := range opts.Senders {
+		if !res.Senders[i].PublicKey().Equal(opts.Senders[i].PublicKey()) {
+			t.Fatalf("sender #%d differs", i)
+		}
+	}
+	res.Senders = opts.Senders
+	if res.TransferType != opts.TransferType || res.TxCount != opts.TxCount || res.ToCount != opts.ToCount ||
+		res.SignersCount != opts.SignersCount || res.WitnessScope != opts.WitnessScope ||
+		res.SendersDistribution != opts.SendersDistribution || res.ReceiversDistribution != opts.ReceiversDistribution ||
+		res.Seed != opts.Seed {
+		t.Fatalf("expected %+v, got %+v", opts, res)
+	}
+}
+
+func TestBenchOptionsDecodeLegacy(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Encoding of dumps generated before options were versioned.
+	w := io.NewBufBinWriter()
+	w.WriteString(NEOTransfer)
+	w.WriteVarUint(1)
+	w.WriteBytes(first.Bytes())
+	w.WriteVarUint(5)
+	w.WriteU64LE(1000)
+
+	var res BenchOptions
+	r := io.NewBinReaderFromBuf(w.Bytes())
+	res.DecodeBinary(r)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if res.TransferType != NEOTransfer || res.ToCount != 5 || res.TxCount != 1000 || len(res.Senders) != 1 {
+		t.Fatalf("unexpected options %+v", res)
+	}
+	if res.SignersCount != 1 || res.WitnessScope != ScopeCalledByEntry ||
+		res.SendersDistribution != DistRoundRobin || res.ReceiversDistribution != DistRoundRobin {
+		t.Fatalf("unexpected defaults %+v", res)
+	}
+	if err := res.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBenchOptionsDecodeUnknownVersion(t *testing.T) {
+	var res BenchOptions
+	r := io.NewBinReaderFromBuf([]byte{optionsMagic, optionsVersion + 1})
+	res.DecodeBinary(r)
+	if r.Err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestBenchOptionsValidateContractSender(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := keys.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		typ    string
+		sender *keys.PrivateKey
+		ok     bool
+	}{
+		{ContractTransfer, first, true},
+		{ContractTransfer, custom, false},
+		{NEOTransfer, custom, true},
+	}
+	for _, tc := range tests {
+		opts := BenchOptions{
+			TransferType:          tc.typ,
+			Senders:               NewSenders(tc.sender, 2, 42),
+			SignersCount:          1,
+			WitnessScope:          ScopeCalledByEntry,
+			SendersDistribution:   DistRoundRobin,
+			ReceiversDistribution: DistRoundRobin,
+		}
+		if err := opts.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s from %s: unexpected error %v", tc.typ, tc.sender.Address(), err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	profilerParams struct {
+		endpoints   []string
+		kinds       []string
+		at          []time.Duration
+		cpuDuration time.Duration
+		prefix      string
+	}
+
+	// Profiler interface.
+	Profiler interface {
+		// Run captures profiles at the configured moments since start.
+		Run(ctx context.Context, start time.Time)
+	}
+
+	profiler struct {
+		*log.Logger
+
+		cli         *http.Client
+		endpoints   []string
+		kinds       []string
+		at          []time.Duration
+		cpuDuration time.Duration
+		prefix      string
+	}
+
+	// ProfilerOption is an option type to configure profiler.
+	ProfilerOption func(*profilerParams)
+)
+
+const (
+	// ProfileCPU is a CPU profile.
+	ProfileCPU = "cpu"
+	// ProfileHeap is a heap profile.
+	ProfileHeap = "heap"
+	// ProfileGoroutine is a goroutines profile.
+	ProfileGoroutine = "goroutine"
+	// ProfileMutex is a mutex contention profile, it's empty unless the node
+	// enables mutex profiling.
+	ProfileMutex = "mutex"
+)
+
+// DefaultProfiles are the profiles captured if no kinds are specified.
+var DefaultProfiles = []string{ProfileCPU, ProfileHeap, ProfileGoroutine, ProfileMutex}
+
+// ProfileEndpoints sets pprof endpoints of nodes, endpoint without scheme is
+// treated as http://<endpoint>.
+func ProfileEndpoints(endpoints []string) ProfilerOption {
+	return func(p *profilerParams) {
+		p.endpoints = endpoints
+	}
+}
+
+// ProfileKinds sets kinds of captured profiles.
+func ProfileKinds(kinds []string) ProfilerOption {
+	return func(p *profilerParams) {
+		p.kinds = kinds
+	}
+}
+
+// ProfileAt sets moments since the start to capture profiles at.
+func ProfileAt(at []time.Duration) ProfilerOption {
+	return func(p *profilerParams) {
+		p.at = at
+	}
+}
+
+// ProfileCPUDuration sets duration of CPU profiling.
+func ProfileCPUDuration(dur time.Duration) ProfilerOption {
+	return func(p *profilerParams) {
+		p.cpuDuration = dur
+	}
+}
+
+// ProfileOutput sets path prefix of profile files, the endpoint, profile kind
+// and moment are appended to it.
+func ProfileOutput(prefix string) ProfilerOption {
+	return func(p *profilerParams) {
+		p.prefix = prefix
+	}
+}
+
+// NewProfiler creates Profiler, to capture pprof profiles of nodes.
+func NewProfiler(opts ...ProfilerOption) (Profiler, error) {
+	p := &profilerParams{
+		kinds:       DefaultProfiles,
+		cpuDuration: 10 * time.Second,
+		prefix:      "profile",
+	}
+
+	for i := range opts {
+		opts[i](p)
+	}
+
+	switch {
+	case len(p.endpoints) == 0:
+		return nil, errors.New("no pprof endpoints")
+	case len(p.at) == 0:
+		return nil, errors.New("no moments to capture profiles at")
+	case p.cpuDuration < time.Second:
+		return nil, fmt.Errorf("CPU profiling duration should be at least 1s, got %s", p.cpuDuration)
+	}
+
+	for _, k := range p.kinds {
+		if !slices.Contains(DefaultProfiles, k) {
+			return nil, fmt.Errorf("unknown profile kind: %s", k)
+		}
+	}
+
+	pr := &profiler{
+		cli:         &http.Client{Timeout: p.cpuDuration + DefaultTimeout},
+		kinds:       p.kinds,
+		at:          slices.Sorted(slices.Values(p.at)),
+		cpuDuration: p.cpuDuration,
+		prefix:      p.prefix,
+		Logger:      log.New(os.Stdout, "", log.LstdFlags),
+	}
+
+	for _, e := range p.endpoints {
+		if !strings.Contains(e, "://") {
+			e = "http://" + e
+		}
+		pr.endpoints = append(pr.endpoints, strings.TrimSuffix(e, "/"))
+	}
+
+	return pr, nil
+}
+
+// Run captures profiles at the configured moments since start.
+func (p *profiler) Run(ctx context.Context, start time.Time) {
+	for _, at := range p.at {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(start.Add(at))):
+		}
+
+		p.Printf("Capture profiles at %s", at)
+
+		var wg sync.WaitGroup
+		for _, e := range p.endpoints {
+			for _, kind := range p.kinds {
+				wg.Go(func() {
+					if err := p.capture(ctx, e, kind, at); err != nil {
+						p.Printf("Could not capture %s profile of %s: %v", kind, e, err)
+					}
+				})
+			}
+		}
+		wg.Wait()
+	}
+}
+
+func (p *profiler) capture(ctx context.Context, endpoint, kind string, at time.Duration) error {
+	url := endpoint + "/debug/pprof/" + kind
+	if kind == ProfileCPU {
+		url = fmt.Sprintf("%s/debug/pprof/profile?seconds=%d", endpoint, int(p.cpuDuration.Seconds()))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	host := strings.NewReplacer(":", "_", "/", "_").Replace(strings.SplitN(endpoint, "://", 2)[1])
+	path := fmt.Sprintf("%s.%s.%s.%s.pb.gz", p.prefix, host, kind, at)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+
+	p.Printf("Saved %s profile of %s to %s", kind, endpoint, filepath.Base(path))
+	return f.Close()
+}
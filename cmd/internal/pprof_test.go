@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type pprofRequest struct {
+	url string
+	at  time.Time
+}
+
+func newPprofServer(t *testing.T) (*httptest.Server, func() []pprofRequest) {
+	var (
+		mu   sync.Mutex
+		reqs []pprofRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs = append(reqs, pprofRequest{url: r.URL.String(), at: time.Now()})
+		mu.Unlock()
+
+		// Mutex profiling isn't enabled on the node.
+		if strings.HasSuffix(r.URL.Path, "/"+ProfileMutex) {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []pprofRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(reqs)
+	}
+}
+
+func TestProfilerRun(t *testing.T) {
+	srv, requests := newPprofServer(t)
+	dir := t.TempDir()
+	pr, err := NewProfiler(
+		ProfileEndpoints([]string{strings.TrimPrefix(srv.URL, "http://") + "/"}),
+		ProfileKinds([]string{ProfileCPU, ProfileHeap, ProfileMutex}),
+		ProfileAt([]time.Duration{100 * time.Millisecond, 0}),
+		ProfileCPUDuration(2*time.Second),
+		ProfileOutput(filepath.Join(dir, "profile")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	pr.Run(context.Background(), start)
+
+	reqs := requests()
+	if len(reqs) != 6 {
+		t.Fatalf("expected 6 requests, got %v", reqs)
+	}
+	for i, r := range reqs {
+		// Moments are sorted, so the last three requests are sent later.
+		if i >= 3 && r.at.Before(start.Add(100*time.Millisecond)) {
+			t.Errorf("request %s is sent too early", r.url)
+		}
+	}
+	var urls []string
+	for _, r := range reqs[:3] {
+		urls = append(urls, r.url)
+	}
+	slices.Sort(urls)
+	if want := []string{"/debug/pprof/heap", "/debug/pprof/mutex", "/debug/pprof/profile?seconds=2"}; !slices.Equal(urls, want) {
+		t.Fatalf("expected %v, got %v", want, urls)
+	}
+
+	host := strings.NewReplacer(":", "_").Replace(strings.TrimPrefix(srv.URL, "http://"))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, e := range entries {
+		files = append(files, e.Name())
+	}
+	// Failed mutex captures leave no files.
+	want := []string{
+		"profile." + host + ".cpu.0s.pb.gz",
+		"profile." + host + ".cpu.100ms.pb.gz",
+		"profile." + host + ".heap.0s.pb.gz",
+		"profile." + host + ".heap.100ms.pb.gz",
+	}
+	if !slices.Equal(files, want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, want[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "/debug/pprof/heap" {
+		t.Fatalf("unexpected profile %q", data)
+	}
+}
+
+func TestProfilerRunCancel(t *testing.T) {
+	srv, requests := newPprofServer(t)
+	pr, err := NewProfiler(
+		ProfileEndpoints([]string{srv.URL}),
+		ProfileKinds([]string{ProfileHeap}),
+		ProfileAt([]time.Duration{0, time.Hour}),
+		ProfileOutput(filepath.Join(t.TempDir(), "profile")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		pr.Run(ctx, time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("profiler isn't stopped")
+	}
+	if reqs := requests(); len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %v", reqs)
+	}
+}
+
+func TestNewProfilerInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ProfilerOption
+	}{
+		{"no endpoints", []ProfilerOption{ProfileAt([]time.Duration{0})}},
+		{"no moments", []ProfilerOption{ProfileEndpoints([]string{"node:30001"})}},
+		{"short CPU profile", []ProfilerOption{ProfileEndpoints([]string{"node:30001"}),
+			ProfileAt([]time.Duration{0}), ProfileCPUDuration(time.Millisecond)}},
+		{"unknown kind", []ProfilerOption{ProfileEndpoints([]string{"node:30001"}),
+			ProfileAt([]time.Duration{0}), ProfileKinds([]string{"block"})}},
+	}
+	for _, tc := range tests {
+		if _, err := NewProfiler(tc.opts...); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
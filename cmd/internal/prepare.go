@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -19,9 +20,6 @@ import (
 	"github.com/nspcc-dev/neo-go/pkg/io"
 	"github.com/nspcc-dev/neo-go/pkg/neorpc/result"
 	"github.com/nspcc-dev/neo-go/pkg/rpcclient"
-	"github.com/nspcc-dev/neo-go/pkg/rpcclient/gas"
-	"github.com/nspcc-dev/neo-go/pkg/rpcclient/invoker"
-	"github.com/nspcc-dev/neo-go/pkg/rpcclient/neo"
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract"
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract/callflag"
 	"github.com/nspcc-dev/neo-go/pkg/smartcontract/manifest"
@@ -30,73 +28,113 @@ import (
 	"github.com/nspcc-dev/neo-go/pkg/vm/emit"
 	"github.com/nspcc-dev/neo-go/pkg/vm/opcode"
 	"github.com/nspcc-dev/neo-go/pkg/vm/stackitem"
+	"github.com/nspcc-dev/neo-go/pkg/vm/vmstate"
 	"github.com/nspcc-dev/neo-go/pkg/wallet"
 )
 
+// fundingBatchSize is the number of senders funded by a single transaction.
+const fundingBatchSize = 100
+
+// DefaultVUBIncrement is used if node doesn't report MaxValidUntilBlockIncrement.
+const DefaultVUBIncrement = 1000
+
+// PrepareResult describes the chain state reached after preparation.
+type PrepareResult struct {
+	Height    uint32       `json:"height"`
+	BlockHash util.Uint256 `json:"blockhash"`
+	// StateRoot is empty if the node doesn't provide state roots.
+	StateRoot util.Uint256 `json:"stateroot"`
+}
+
 // Prepare sends prepare transactions on chain at runtime.
-func (d *doer) Prepare(ctx context.Context, vote bool, opts BenchOptions) {
+func (d *doer) Prepare(ctx context.Context, committee []*keys.PrivateKey, vote bool, opts BenchOptions) {
+	if _, err := PrepareChain(ctx, d.cli.addr[0], committee, vote, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// PrepareChain funds senders, votes for candidates and deploys token contract
+// using the node with the given RPC endpoint, it returns the resulting chain state.
+// Nil committee means default keys of the known networks.
+func PrepareChain(ctx context.Context, endpoint string, committee []*keys.PrivateKey, vote bool, opts BenchOptions) (*PrepareResult, error) {
 	log.Println("Prepare chain for benchmark")
 
 	// Preparation stage isn't done during main benchmark,
 	// so using native client doesn't play a big role.
-	c, err := rpcclient.New(ctx, d.cli.addr[0], rpcclient.Options{})
+	c, err := rpcclient.New(ctx, endpoint, rpcclient.Options{})
 	if err != nil {
-		log.Fatalf("could not create client: %v", err)
+		return nil, fmt.Errorf("could not create client: %w", err)
 	}
 
 	err = c.Init()
 	if err != nil {
-		log.Fatalf("could not init client: %v", err)
+		return nil, fmt.Errorf("could not init client: %w", err)
 	}
 
 	v, err := c.GetVersion()
 	if err != nil {
-		log.Fatalf("could not get the number of validators: %v", err)
+		return nil, fmt.Errorf("could not get the number of validators: %w", err)
 	}
 
 	log.Printf("Determined validators count: %d", v.Protocol.ValidatorsCount)
 
-	sgn, err := initChain(int(v.Protocol.ValidatorsCount))
+	sgn, err := initChain(int(v.Protocol.ValidatorsCount), committee)
 	if err != nil {
-		log.Fatalf("could not initialize chain: %v", err)
+		return nil, fmt.Errorf("could not initialize chain: %w", err)
 	}
 
 	err = fillChain(ctx, c, v.Protocol, sgn, vote, opts)
 	if err != nil {
-		log.Fatalf("could not create blocks: %v", err)
+		return nil, fmt.Errorf("could not create blocks: %w", err)
 	}
+
+	return chainState(c)
 }
 
-func initChain(validatorCount int) (*signer, error) {
-	var wifs []string
-	switch validatorCount {
-	case 1:
-		wifs = []string{"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY"}
-	case 4:
-		wifs = []string{
-			"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
-			"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
-			"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
-			"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
-		}
-	case 7:
-		wifs = []string{
-			"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
-			"L392JMYfi7EUG3mjokQXnbVKJw1MdF42ZHe68xe5FPUEykHew7bS",
-			"L3suCMDA85Wwprk7fRqBV75ddQ3KSDh1CbapD6SXDGV6bMWnvBBK",
-			"L1SobH6JpM68XJRHWgQVM8X858zFRPHHpVULr95yAdHo4CBsb5Zu",
-			"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
-			"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
-			"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+// chainState returns the current chain height, block hash and state root.
+func chainState(c *rpcclient.Client) (*PrepareResult, error) {
+	count, err := c.GetBlockCount()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch block count: %w", err)
+	}
+
+	res := &PrepareResult{Height: count - 1}
+	res.BlockHash, err = c.GetBlockHash(res.Height)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch block hash: %w", err)
+	}
+
+	root, err := c.GetStateRootByHeight(res.Height)
+	if err != nil {
+		log.Printf("could not fetch state root: %v", err)
+	} else {
+		res.StateRoot = root.Root
+	}
+
+	log.Printf("Chain is prepared at height %d, block %s, state root %s", res.Height, res.BlockHash.StringLE(), res.StateRoot.StringLE())
+	return res, nil
+}
+
+// initChain returns the signer of standby validators multisignature account,
+// validators are the first validatorCount keys of the committee.
+func initChain(validatorCount int, committee []*keys.PrivateKey) (*signer, error) {
+	if committee == nil {
+		var err error
+		committee, err = defaultCommittee(validatorCount)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("invalid validators count: %d", validatorCount)
+	}
+	if len(committee) < validatorCount {
+		return nil, fmt.Errorf("not enough committee keys: %d for %d validators", len(committee), validatorCount)
 	}
 
-	return newSigner(wifs...)
+	return newSigner(committee[:validatorCount]...)
 }
 
-func newDeployTx(mgmtHash util.Uint160, priv *keys.PrivateKey, nefName, manifestName string) (*transaction.Transaction, util.Uint160, error) {
+// newDeployTx returns signed contract deploy transaction along with the hash
+// of the contract.
+func newDeployTx(mgmtHash util.Uint160, priv *keys.PrivateKey, nefName, manifestName string, vub uint32) (*transaction.Transaction, util.Uint160, error) {
 	rawNef, err := os.ReadFile(nefName)
 	if err != nil {
 		return nil, util.Uint160{}, err
@@ -115,7 +153,7 @@ func newDeployTx(mgmtHash util.Uint160, priv *keys.PrivateKey, nefName, manifest
 
 	tx := transaction.New(buf.Bytes(), 100*native.GASFactor)
 	tx.Signers = []transaction.Signer{{Account: priv.GetScriptHash(), Scopes: transaction.Global}}
-	tx.ValidUntilBlock = 1000
+	tx.ValidUntilBlock = vub
 	tx.NetworkFee = 10_000000
 
 	// Contract hash is immutable so we calculate it once and then reuse during tx generation.
@@ -134,40 +172,12 @@ func newDeployTx(mgmtHash util.Uint160, priv *keys.PrivateKey, nefName, manifest
 	return tx, h, acc.SignTx(netmode.PrivNet, tx)
 }
 
-func newNEP5Transfer(validatorCount int, sc util.Uint160, from, to util.Uint160, amount int64) *transaction.Transaction {
-	w := io.NewBufBinWriter()
-	emit.AppCall(w.BinWriter, sc, "transfer", callflag.All, from, to, amount, nil)
-	emit.Opcodes(w.BinWriter, opcode.ASSERT)
-
-	script := w.Bytes()
-	tx := transaction.New(script, 11000000)
-	switch validatorCount {
-	case 1:
-		tx.NetworkFee = 1500000
-	case 4:
-		tx.NetworkFee = 4500000
-	default:
-		tx.NetworkFee = 8000000
-	}
-	tx.ValidUntilBlock = 1000
-	tx.Signers = append(tx.Signers, transaction.Signer{
-		Account: from,
-		Scopes:  transaction.CalledByEntry,
-	})
-	return tx
-}
-
 func fillChain(ctx context.Context, c *rpcclient.Client, proto result.Protocol, sgn *signer, vote bool, opts BenchOptions) error {
-	// minAwaitThreshold is the minimum required threshold for setup transactions to be awaited.
-	var minAwaitThreshold = 3 * time.Second
-
 	cs, err := c.GetNativeContracts()
 	if err != nil {
 		return err
 	}
 
-	timeout := max(3*time.Duration(proto.MillisecondsPerBlock)*time.Millisecond, minAwaitThreshold)
-
 	var neoHash, gasHash, mgmtHash util.Uint160
 	for i := range cs {
 		switch cs[i].Manifest.Name {
@@ -181,132 +191,419 @@ func fillChain(ctx context.Context, c *rpcclient.Client, proto result.Protocol,
 	}
 
 	if vote {
-		err = registerCandidates(ctx, neoHash, c, sgn)
+		err = registerCandidates(ctx, c, proto, neoHash, sgn)
 		if err != nil {
 			return err
 		}
 	}
 
-	txs := make([]*transaction.Transaction, 0, len(opts.Senders)*2)
-	neoAmount := int64(native.NEOTotalSupply / len(opts.Senders))
-	gasAmount := int64(native.GASFactor * 2900000 / len(opts.Senders))
-	for _, priv := range opts.Senders {
-		txMoveNeo := newNEP5Transfer(len(sgn.privs), neoHash, sgn.addr, priv.GetScriptHash(), neoAmount)
-		txMoveGas := newNEP5Transfer(len(sgn.privs), gasHash, sgn.addr, priv.GetScriptHash(), gasAmount)
-		sgn.signTx(txMoveNeo, txMoveGas)
-		txs = append(txs, txMoveNeo, txMoveGas)
-	}
-
-	log.Println("Sending NEO and GAS transfer tx")
-	err = sendTx(ctx, c, txs...)
-	if err != nil {
-		return err
-	}
-
-	inv := invoker.New(c, nil)
-	neoC := neo.NewReader(inv)
-	gasC := gas.NewReader(inv)
-	fs := make([]func() (bool, error), 0, len(opts.Senders)*2)
-	for i := range opts.Senders {
-		addr := opts.Senders[i].GetScriptHash()
-		fs = append(fs,
-			func() (bool, error) {
-				b, err := neoC.BalanceOf(addr)
-				if err != nil {
-					return false, err
-				}
-				return b.Sign() > 0, err
-			},
-			func() (bool, error) {
-				b, err := gasC.BalanceOf(addr)
-				if err != nil {
-					return false, err
-				}
-				return b.Sign() > 0, err
-			})
-	}
-	err = awaitTx(ctx, timeout, fs...)
-
+	err = fundSenders(ctx, c, proto, sgn, neoHash, gasHash, opts.Senders)
 	if err != nil {
 		return err
 	}
 
 	if vote {
-		err = voteForCandidates(ctx, neoHash, c, sgn, opts.Senders)
+		err = voteForCandidates(ctx, c, proto, neoHash, sgn, opts.Senders)
 		if err != nil {
 			return err
 		}
 	}
 
+	return deployContract(ctx, c, proto, mgmtHash, opts.Senders[0])
+}
+
+// deployContract deploys token contract unless it's deployed already.
+func deployContract(ctx context.Context, c *rpcclient.Client, proto result.Protocol, mgmtHash util.Uint160, priv *keys.PrivateKey) error {
+	height, err := c.GetBlockCount()
+	if err != nil {
+		return fmt.Errorf("could not fetch block count: %w", err)
+	}
+
 	// We deploy contract from priv to avoid having different hashes for single/4-node benchmarks.
 	// The contract is taken from `examples/token` of neo-go with 2 minor corrections:
 	// 1. Owner address is replaced with the address of WIF we use.
 	// 2. All funds are minted to owner in `_deploy`.
-	txDeploy, h, err := newDeployTx(mgmtHash, opts.Senders[0], "/tokencontract/token.nef",
-		"/tokencontract/token.manifest.json")
+	txDeploy, h, err := newDeployTx(mgmtHash, priv, "/tokencontract/token.nef",
+		"/tokencontract/token.manifest.json", validUntilBlock(height, proto))
 	if err != nil {
 		return err
 	}
 
+	if _, err := c.GetContractStateByHash(h); err == nil {
+		log.Println("Contract is deployed already")
+		return nil
+	}
+
 	log.Println("Sending contract deploy tx")
 	err = sendTx(ctx, c, txDeploy)
 	if err != nil {
 		return err
 	}
 
-	return awaitTx(ctx, timeout,
-		func() (bool, error) {
-			_, err := c.GetContractStateByHash(h)
-			log.Println("Contract was persisted:", err == nil)
-			return err == nil, err
-		})
+	return awaitInclusion(ctx, c, awaitTimeout(proto), height, []*transaction.Transaction{txDeploy})
 }
 
-func registerCandidates(ctx context.Context, neoHash util.Uint160, c *rpcclient.Client, sgn *signer) error {
-	for _, p := range sgn.privs {
-		tx := newRegisterTx(neoHash, p, sgn)
-		err := sendTx(ctx, c, tx)
+// fundSenders transfers NEO and GAS from the committee to senders, on a fresh
+// chain (no sender has any funds) the whole NEO supply and 2.9M GAS are split
+// equally between them. If senders have funds already (the chain is prepared
+// before), only those who spent more than a half of their share are topped
+// up. Transfers are batched into a few transactions, all of them are sent at
+// once and then awaited by block inclusion.
+func fundSenders(ctx context.Context, c *rpcclient.Client, proto result.Protocol, sgn *signer, neoHash, gasHash util.Uint160, senders []*keys.PrivateKey) error {
+	var (
+		neoAmount = big.NewInt(int64(native.NEOTotalSupply / len(senders)))
+		gasAmount = big.NewInt(int64(native.GASFactor * 2900000 / len(senders)))
+		tokens    = []struct {
+			name   string
+			hash   util.Uint160
+			amount *big.Int
+			total  *big.Int
+		}{{"NEO", neoHash, neoAmount, new(big.Int)}, {"GAS", gasHash, gasAmount, new(big.Int)}}
+		balances = make([]*big.Int, 0, len(tokens)*len(senders))
+		fresh    = true
+		scripts  [][]byte
+		txs      []*transaction.Transaction
+		funded   int
+	)
+
+	height, err := c.GetBlockCount()
+	if err != nil {
+		return fmt.Errorf("could not fetch block count: %w", err)
+	}
+	vub := validUntilBlock(height, proto)
+
+	for i := 0; i < len(senders); i += fundingBatchSize {
+		batch := senders[i:min(i+fundingBatchSize, len(senders))]
+
+		w := io.NewBufBinWriter()
+		for _, priv := range batch {
+			for _, t := range tokens {
+				emit.AppCall(w.BinWriter, t.hash, "balanceOf", callflag.ReadStates, priv.GetScriptHash())
+			}
+		}
+		items, err := invokeBatch(c, w, len(tokens)*len(batch))
 		if err != nil {
-			return err
+			return fmt.Errorf("could not fetch balances: %w", err)
+		}
+		for _, item := range items {
+			balance, err := item.TryInteger()
+			if err != nil {
+				return fmt.Errorf("invalid balance: %w", err)
+			}
+			fresh = fresh && balance.Sign() == 0
+			balances = append(balances, balance)
 		}
 	}
 
-	return awaitTx(ctx, time.Second*15, func() (bool, error) {
-		res, err := c.InvokeFunction(neoHash, "getCandidates", []smartcontract.Parameter{}, nil)
+	for i := 0; i < len(senders); i += fundingBatchSize {
+		w := io.NewBufBinWriter()
+		for j, priv := range senders[i:min(i+fundingBatchSize, len(senders))] {
+			transfers := 0
+			for k, t := range tokens {
+				balance := balances[len(tokens)*(i+j)+k]
+				if !fresh && new(big.Int).Lsh(balance, 1).Cmp(t.amount) >= 0 {
+					continue
+				}
+				amount := new(big.Int).Sub(t.amount, balance)
+				t.total.Add(t.total, amount)
+				emit.AppCall(w.BinWriter, t.hash, "transfer", callflag.All,
+					sgn.addr, priv.GetScriptHash(), amount, nil)
+				emit.Opcodes(w.BinWriter, opcode.ASSERT)
+				transfers++
+			}
+			if transfers > 0 {
+				funded++
+			}
+		}
+		if w.Err != nil {
+			return w.Err
+		}
+		if w.Len() != 0 {
+			scripts = append(scripts, bytes.Clone(w.Bytes()))
+		}
+	}
+
+	if len(scripts) == 0 {
+		log.Printf("All %d senders are funded already", len(senders))
+		return nil
+	}
+
+	w := io.NewBufBinWriter()
+	for _, t := range tokens {
+		emit.AppCall(w.BinWriter, t.hash, "balanceOf", callflag.ReadStates, sgn.addr)
+	}
+	items, err := invokeBatch(c, w, len(tokens))
+	if err != nil {
+		return fmt.Errorf("could not fetch committee balances: %w", err)
+	}
+	for k, t := range tokens {
+		balance, err := items[k].TryInteger()
 		if err != nil {
-			return false, err
+			return fmt.Errorf("invalid committee balance: %w", err)
 		}
-		return len(res.Stack) == 1 && len(res.Stack[0].Value().([]stackitem.Item)) == len(sgn.privs), nil
-	})
-}
+		if balance.Cmp(t.total) < 0 {
+			return fmt.Errorf("committee has %s %s, %s is needed to fund %d senders", balance, t.name, t.total, funded)
+		}
+	}
 
-func voteForCandidates(ctx context.Context, neoHash util.Uint160, c *rpcclient.Client, sgn *signer, senders []*keys.PrivateKey) error {
-	for i := range senders {
-		tx := newVoteTx(neoHash, senders[i], sgn.privs[i%len(sgn.privs)].PublicKey())
-		err := sendTx(ctx, c, tx)
+	for _, script := range scripts {
+		tx, err := newCommitteeTx(c, sgn, script, vub)
 		if err != nil {
-			return err
+			return fmt.Errorf("could not create funding tx #%d: %w", len(txs), err)
 		}
+		txs = append(txs, tx)
+	}
+
+	log.Printf("Sending %d NEO and GAS transfer txs for %d/%d senders", len(txs), funded, len(senders))
+	err = sendTx(ctx, c, txs...)
+	if err != nil {
+		return err
+	}
+
+	return awaitInclusion(ctx, c, awaitTimeout(proto), height, txs)
+}
+
+// invokeBatch invokes script and returns the resulting stack which should
+// contain exactly n items.
+func invokeBatch(c *rpcclient.Client, w *io.BufBinWriter, n int) ([]stackitem.Item, error) {
+	if w.Err != nil {
+		return nil, w.Err
+	}
+	res, err := c.InvokeScript(w.Bytes(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.State != vmstate.Halt.String() {
+		return nil, fmt.Errorf("script fails: %s", res.FaultException)
+	}
+	if len(res.Stack) != n {
+		return nil, fmt.Errorf("unexpected stack length: %d instead of %d", len(res.Stack), n)
+	}
+	return res.Stack, nil
+}
+
+// newCommitteeTx returns transaction with the given script signed by the
+// committee, fees are calculated by the node.
+func newCommitteeTx(c *rpcclient.Client, sgn *signer, script []byte, vub uint32) (*transaction.Transaction, error) {
+	tx := transaction.New(script, 0)
+	tx.ValidUntilBlock = vub
+	tx.Signers = []transaction.Signer{{
+		Account: sgn.addr,
+		Scopes:  transaction.CalledByEntry,
+	}}
+
+	res, err := c.InvokeScript(script, tx.Signers)
+	if err != nil {
+		return nil, err
+	}
+	if res.State != vmstate.Halt.String() {
+		return nil, fmt.Errorf("script fails: %s", res.FaultException)
+	}
+	tx.SystemFee = res.GasConsumed
+
+	tx.Scripts = []transaction.Witness{{VerificationScript: sgn.script}}
+	tx.NetworkFee, err = c.CalculateNetworkFee(tx)
+	if err != nil {
+		return nil, err
 	}
 
-	return awaitTx(ctx, time.Second*15, func() (bool, error) {
-		res, err := c.InvokeFunction(neoHash, "getCandidates", []smartcontract.Parameter{}, nil)
+	sgn.signTx(tx)
+	return tx, nil
+}
+
+// validUntilBlock returns the maximum ValidUntilBlock value for transactions
+// sent at the given block count.
+func validUntilBlock(blockCount uint32, proto result.Protocol) uint32 {
+	increment := proto.MaxValidUntilBlockIncrement
+	if increment == 0 {
+		increment = DefaultVUBIncrement
+	}
+	return blockCount - 1 + increment
+}
+
+// awaitTimeout returns the time to wait for prepare transactions to persist.
+func awaitTimeout(proto result.Protocol) time.Duration {
+	// minAwaitThreshold is the minimum required threshold for setup transactions to be awaited.
+	const minAwaitThreshold = 3 * time.Second
+
+	return max(3*time.Duration(proto.MillisecondsPerBlock)*time.Millisecond, minAwaitThreshold)
+}
+
+// awaitInclusion waits for transactions to be included into blocks starting
+// from the given one. It fails if there is no progress during timeout or if
+// any of transactions fails.
+func awaitInclusion(ctx context.Context, c *rpcclient.Client, timeout time.Duration, start uint32, txs []*transaction.Transaction) error {
+	const retryInterval = time.Millisecond * 500
+
+	pending := make(map[util.Uint256]struct{}, len(txs))
+	for _, tx := range txs {
+		pending[tx.Hash()] = struct{}{}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for len(pending) > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		count, err := c.GetBlockCount()
 		if err != nil {
-			return false, err
+			return fmt.Errorf("could not fetch block count: %w", err)
 		}
-		var cnt big.Int
-		for _, it := range res.Stack[0].Value().([]stackitem.Item) {
-			votes, err := it.Value().([]stackitem.Item)[1].TryInteger()
+		for ; start < count; start++ {
+			blk, err := c.GetBlockByIndex(start)
 			if err != nil {
-				return false, err
+				return fmt.Errorf("could not fetch block #%d: %w", start, err)
+			}
+			persisted := 0
+			for _, tx := range blk.Transactions {
+				if _, ok := pending[tx.Hash()]; !ok {
+					continue
+				}
+				if err := checkTxState(c, tx.Hash()); err != nil {
+					return err
+				}
+				delete(pending, tx.Hash())
+				persisted++
+			}
+			if persisted > 0 {
+				log.Printf("Block #%d: %d/%d prepare txs are persisted", start, len(txs)-len(pending), len(txs))
+				deadline = time.Now().Add(timeout)
 			}
-			cnt.Add(&cnt, votes)
 		}
-		expected := int64(native.NEOTotalSupply / len(senders) * len(senders))
-		return cnt.Int64() == expected, nil
-	})
+
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout while waiting for %d prepare txs to persist", len(pending))
+		}
+		time.Sleep(retryInterval)
+	}
+	return nil
+}
+
+// checkTxState checks that persisted transaction is successfully executed.
+func checkTxState(c *rpcclient.Client, h util.Uint256) error {
+	aer, err := c.GetApplicationLog(h, nil)
+	if err != nil {
+		return fmt.Errorf("could not fetch application log of %s: %w", h.StringLE(), err)
+	}
+	if len(aer.Executions) == 0 || aer.Executions[0].VMState != vmstate.Halt {
+		var exception string
+		if len(aer.Executions) != 0 {
+			exception = aer.Executions[0].FaultException
+		}
+		return fmt.Errorf("prepare tx %s fails: %s", h.StringLE(), exception)
+	}
+	return nil
+}
+
+// registerCandidates registers committee members as candidates unless they're registered already.
+func registerCandidates(ctx context.Context, c *rpcclient.Client, proto result.Protocol, neoHash util.Uint160, sgn *signer) error {
+	res, err := c.InvokeFunction(neoHash, "getCandidates", []smartcontract.Parameter{}, nil)
+	if err != nil {
+		return err
+	}
+	if len(res.Stack) != 1 {
+		return fmt.Errorf("unexpected getCandidates result stack length: %d", len(res.Stack))
+	}
+	candidates, ok := res.Stack[0].Value().([]stackitem.Item)
+	if !ok {
+		return errors.New("invalid getCandidates result")
+	}
+	registered := make(map[string]struct{}, len(candidates))
+	for _, it := range candidates {
+		fields, ok := it.Value().([]stackitem.Item)
+		if !ok || len(fields) == 0 {
+			return errors.New("invalid candidate")
+		}
+		key, err := fields[0].TryBytes()
+		if err != nil {
+			return fmt.Errorf("invalid candidate key: %w", err)
+		}
+		registered[string(key)] = struct{}{}
+	}
+
+	height, err := c.GetBlockCount()
+	if err != nil {
+		return fmt.Errorf("could not fetch block count: %w", err)
+	}
+	vub := validUntilBlock(height, proto)
+
+	var txs []*transaction.Transaction
+	for _, p := range sgn.privs {
+		if _, ok := registered[string(p.PublicKey().Bytes())]; ok {
+			continue
+		}
+		tx, err := newRegisterTx(c, neoHash, p, sgn, vub)
+		if err != nil {
+			return fmt.Errorf("could not create register tx: %w", err)
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		log.Println("All candidates are registered already")
+		return nil
+	}
+
+	log.Printf("Registering %d candidates", len(txs))
+	err = sendTx(ctx, c, txs...)
+	if err != nil {
+		return err
+	}
+
+	return awaitInclusion(ctx, c, awaitTimeout(proto), height, txs)
+}
+
+// voteForCandidates makes every sender vote for one of the candidates, senders
+// that have voted for the expected candidate already are skipped.
+func voteForCandidates(ctx context.Context, c *rpcclient.Client, proto result.Protocol, neoHash util.Uint160, sgn *signer, senders []*keys.PrivateKey) error {
+	height, err := c.GetBlockCount()
+	if err != nil {
+		return fmt.Errorf("could not fetch block count: %w", err)
+	}
+	vub := validUntilBlock(height, proto)
+
+	var txs []*transaction.Transaction
+	for i := 0; i < len(senders); i += fundingBatchSize {
+		batch := senders[i:min(i+fundingBatchSize, len(senders))]
+
+		w := io.NewBufBinWriter()
+		for _, priv := range batch {
+			emit.AppCall(w.BinWriter, neoHash, "getAccountState", callflag.ReadStates, priv.GetScriptHash())
+		}
+		states, err := invokeBatch(c, w, len(batch))
+		if err != nil {
+			return fmt.Errorf("could not fetch account states: %w", err)
+		}
+
+		for j, priv := range batch {
+			voteFor := sgn.privs[(i+j)%len(sgn.privs)].PublicKey()
+			// Account state is a structure with the vote target as the third field.
+			if fields, ok := states[j].Value().([]stackitem.Item); ok && len(fields) > 2 {
+				if to, err := fields[2].TryBytes(); err == nil && bytes.Equal(to, voteFor.Bytes()) {
+					continue
+				}
+			}
+			txs = append(txs, newVoteTx(neoHash, priv, voteFor, vub))
+		}
+	}
+	if len(txs) == 0 {
+		log.Printf("All %d senders have voted already", len(senders))
+		return nil
+	}
+
+	log.Printf("Sending %d vote txs", len(txs))
+	err = sendTx(ctx, c, txs...)
+	if err != nil {
+		return err
+	}
+
+	return awaitInclusion(ctx, c, awaitTimeout(proto), height, txs)
 }
-func newVoteTx(neoHash util.Uint160, priv *keys.PrivateKey, voteFor *keys.PublicKey) *transaction.Transaction {
+
+func newVoteTx(neoHash util.Uint160, priv *keys.PrivateKey, voteFor *keys.PublicKey, vub uint32) *transaction.Transaction {
 	w := io.NewBufBinWriter()
 	emit.AppCall(w.BinWriter,
 		neoHash, "vote", callflag.All,
@@ -319,7 +616,7 @@ func newVoteTx(neoHash util.Uint160, priv *keys.PrivateKey, voteFor *keys.Public
 	script := w.Bytes()
 	tx := transaction.New(script, 15_000_000)
 	tx.NetworkFee = 2000_000
-	tx.ValidUntilBlock = 1200
+	tx.ValidUntilBlock = vub
 	tx.Signers = append(tx.Signers, transaction.Signer{
 		Account: priv.GetScriptHash(),
 		Scopes:  transaction.CalledByEntry,
@@ -332,7 +629,7 @@ func newVoteTx(neoHash util.Uint160, priv *keys.PrivateKey, voteFor *keys.Public
 	return tx
 }
 
-func newRegisterTx(neoHash util.Uint160, priv *keys.PrivateKey, sgn *signer) *transaction.Transaction {
+func newRegisterTx(c *rpcclient.Client, neoHash util.Uint160, priv *keys.PrivateKey, sgn *signer, vub uint32) (*transaction.Transaction, error) {
 	w := io.NewBufBinWriter()
 	emit.AppCall(w.BinWriter, neoHash, "registerCandidate",
 		callflag.All, priv.PublicKey().Bytes())
@@ -342,12 +639,7 @@ func newRegisterTx(neoHash util.Uint160, priv *keys.PrivateKey, sgn *signer) *tr
 
 	script := w.Bytes()
 	tx := transaction.New(script, native.DefaultRegisterPrice+5_000_000)
-	if len(sgn.privs) == 1 {
-		tx.NetworkFee = 3000000
-	} else {
-		tx.NetworkFee = 6000000
-	}
-	tx.ValidUntilBlock = 1000
+	tx.ValidUntilBlock = vub
 	tx.Signers = []transaction.Signer{
 		{
 			Account: sgn.addr,
@@ -359,12 +651,22 @@ func newRegisterTx(neoHash util.Uint160, priv *keys.PrivateKey, sgn *signer) *tr
 		},
 	}
 
+	tx.Scripts = []transaction.Witness{
+		{VerificationScript: sgn.script},
+		{VerificationScript: priv.PublicKey().GetVerificationScript()},
+	}
+	var err error
+	tx.NetworkFee, err = c.CalculateNetworkFee(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	sgn.signTx(tx)
-	err := wallet.NewAccountFromPrivateKey(priv).SignTx(netmode.PrivNet, tx)
+	err = wallet.NewAccountFromPrivateKey(priv).SignTx(netmode.PrivNet, tx)
 	if err != nil {
 		panic(err)
 	}
-	return tx
+	return tx, nil
 }
 
 func sendTx(ctx context.Context, c *rpcclient.Client, txs ...*transaction.Transaction) error {
@@ -379,27 +681,3 @@ func sendTx(ctx context.Context, c *rpcclient.Client, txs ...*transaction.Transa
 	}
 	return nil
 }
-
-func awaitTx(ctx context.Context, duration time.Duration, check ...func() (bool, error)) error {
-	const retryInterval = time.Millisecond * 500
-
-	attempts := duration / retryInterval
-
-	for i := 0; i < len(check); {
-		if ctx.Err() != nil {
-			return ctx.Err()
-		}
-		ok, err := check[i]()
-		if err != nil || !ok {
-			if attempts == 0 {
-				return errors.New("timeout while waiting for prepare tx to persist")
-			}
-			attempts--
-			time.Sleep(retryInterval)
-			continue
-		}
-
-		i++
-	}
-	return nil
-}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nspcc-dev/neo-go/pkg/config/netmode"
+	"github.com/nspcc-dev/neo-go/pkg/core/block"
+	"github.com/nspcc-dev/neo-go/pkg/core/state"
+	"github.com/nspcc-dev/neo-go/pkg/core/transaction"
+	nio "github.com/nspcc-dev/neo-go/pkg/io"
+	"github.com/nspcc-dev/neo-go/pkg/neorpc"
+	"github.com/nspcc-dev/neo-go/pkg/neorpc/result"
+	"github.com/nspcc-dev/neo-go/pkg/rpcclient"
+	"github.com/nspcc-dev/neo-go/pkg/smartcontract/trigger"
+	"github.com/nspcc-dev/neo-go/pkg/util"
+	"github.com/nspcc-dev/neo-go/pkg/vm/opcode"
+	"github.com/nspcc-dev/neo-go/pkg/vm/vmstate"
+)
+
+// newChainServer returns RPC client of the chain with the given blocks, faults
+// are exceptions of failed transactions.
+func newChainServer(t *testing.T, blocks [][]*transaction.Transaction, faults map[util.Uint256]string) *rpcclient.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var res any
+		switch req.Method {
+		case "getversion":
+			res = result.Version{Protocol: result.Protocol{Network: netmode.PrivNet}}
+		case "getnativecontracts":
+			res = []any{}
+		case "getblockcount":
+			res = len(blocks)
+		case "getblock":
+			var index uint32
+			_ = json.Unmarshal(req.Params[0], &index)
+			b := block.New(false)
+			b.Index = index
+			b.Transactions = blocks[index]
+			w := nio.NewBufBinWriter()
+			b.EncodeBinary(w.BinWriter)
+			res = w.Bytes()
+		case "getapplicationlog":
+			var h util.Uint256
+			_ = json.Unmarshal(req.Params[0], &h)
+			exec := state.Execution{Trigger: trigger.Application, VMState: vmstate.Halt}
+			if exception, ok := faults[h]; ok {
+				exec.VMState, exec.FaultException = vmstate.Fault, exception
+			}
+			res = result.ApplicationLog{Container: h, IsTransaction: true, Executions: []state.Execution{exec}}
+		default:
+			t.Errorf("unexpected request %s", req.Method)
+		}
+		raw, err := json.Marshal(res)
+		if err != nil {
+			t.Error(err)
+		}
+		_ = json.NewEncoder(w).Encode(neorpc.Response{
+			HeaderAndError: neorpc.HeaderAndError{Header: neorpc.Header{ID: req.ID, JSONRPC: neorpc.JSONRPCVersion}},
+			Result:         raw,
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := rpcclient.New(context.Background(), srv.URL, rpcclient.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func newTestPrepareTx(nonce uint32) *transaction.Transaction {
+	tx := transaction.New([]byte{byte(opcode.RET)}, 0)
+	tx.Nonce = nonce
+	tx.Signers = []transaction.Signer{{Account: util.Uint160{1}}}
+	tx.Scripts = []transaction.Witness{{}}
+	return tx
+}
+
+func TestAwaitInclusion(t *testing.T) {
+	var txs []*transaction.Transaction
+	for i := range 4 {
+		txs = append(txs, newTestPrepareTx(uint32(i)))
+	}
+	// Txs sent before the start block aren't looked for there.
+	blocks := [][]*transaction.Transaction{{txs[3]}, {txs[0]}, {}, {txs[1], txs[2]}}
+
+	tests := []struct {
+		name   string
+		txs    []*transaction.Transaction
+		faults map[util.Uint256]string
+		err    string
+	}{
+		{"persisted", txs[:3], nil, ""},
+		{"fault", txs[:3], map[util.Uint256]string{txs[2].Hash(): "ASSERT failed"}, "fails: ASSERT failed"},
+		{"not persisted", txs, nil, "timeout while waiting for 1 prepare txs to persist"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newChainServer(t, blocks, tc.faults)
+			err := awaitInclusion(context.Background(), c, 10*time.Millisecond, 1, tc.txs)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestPrepareTimings(t *testing.T) {
+	if vub := validUntilBlock(10, result.Protocol{MaxValidUntilBlockIncrement: 100}); vub != 109 {
+		t.Errorf("unexpected ValidUntilBlock %d", vub)
+	}
+	if vub := validUntilBlock(10, result.Protocol{}); vub != 9+DefaultVUBIncrement {
+		t.Errorf("unexpected default ValidUntilBlock %d", vub)
+	}
+	if d := awaitTimeout(result.Protocol{MillisecondsPerBlock: 5000}); d != 15*time.Second {
+		t.Errorf("unexpected timeout %s", d)
+	}
+	if d := awaitTimeout(result.Protocol{MillisecondsPerBlock: 100}); d != 3*time.Second {
+		t.Errorf("unexpected minimum timeout %s", d)
+	}
+}
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"slices"
+
 	"github.com/nspcc-dev/neo-go/pkg/config/netmode"
 	"github.com/nspcc-dev/neo-go/pkg/core/transaction"
 	"github.com/nspcc-dev/neo-go/pkg/crypto/hash"
@@ -18,14 +20,14 @@ type signer struct {
 	pubs   keys.PublicKeys
 }
 
-func newSigner(wifs ...string) (*signer, error) {
+func newSigner(privs ...*keys.PrivateKey) (*signer, error) {
 	var c signer
-	for i := range wifs {
-		priv, err := keys.NewPrivateKeyFromWIF(wifs[i])
-		if err != nil {
-			return nil, err
-		}
-		c.privs = append(c.privs, priv)
+	// CHECKMULTISIG expects signatures in the order of sorted public keys.
+	c.privs = slices.Clone(privs)
+	slices.SortFunc(c.privs, func(a, b *keys.PrivateKey) int {
+		return a.PublicKey().Cmp(b.PublicKey())
+	})
+	for _, priv := range c.privs {
 		c.pubs = append(c.pubs, priv.PublicKey())
 	}
 	var err error
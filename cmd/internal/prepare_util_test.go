@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
+)
+
+func TestNewSignerKeyOrder(t *testing.T) {
+	first, err := keys.NewPrivateKeyFromWIF(FirstSenderWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privs := NewSenders(first, 7, 1)
+
+	sgn, err := newSigner(privs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sort.IsSorted(sgn.pubs) {
+		t.Fatal("public keys aren't sorted")
+	}
+	for i := range sgn.privs {
+		if !sgn.privs[i].PublicKey().Equal(sgn.pubs[i]) {
+			t.Fatalf("private key #%d doesn't match public key", i)
+		}
+	}
+	if privs[0] != first {
+		t.Fatal("caller keys are reordered")
+	}
+}
@@ -0,0 +1,390 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	procState struct {
+		*log.Logger
+
+		per     time.Duration
+		targets []procTarget
+		prev    map[string]procCounters
+	}
+
+	// procTarget is a process or a cgroup to sample resources of.
+	procTarget struct {
+		name   string
+		role   string
+		pid    int
+		cgroup string
+	}
+
+	// procCounters are cumulative counters of process or cgroup.
+	procCounters struct {
+		io  ioCounters
+		cpu float64 // seconds
+		mem float64 // MB
+	}
+)
+
+const (
+	// cgroupRoot is a mount point of cgroup v2 hierarchy.
+	cgroupRoot = "/sys/fs/cgroup"
+	// clockTicks is the number of clock ticks per second in /proc/<pid>/stat,
+	// it's 100 on all Linux architectures.
+	clockTicks = 100
+)
+
+// NewProcStats creates DockerStater, to fetch resource usage by processes and
+// cgroups (see StatProcesses) from /proc and cgroup v2 files without Docker.
+func NewProcStats(ctx context.Context, opts ...StatOption) (DockerStater, error) {
+	p := &dockerStateParams{}
+
+	for i := range opts {
+		opts[i](p)
+	}
+
+	if len(p.procs) == 0 {
+		return nil, errors.New("no processes to fetch stats of")
+	}
+
+	ps := &procState{
+		per:    p.period,
+		prev:   make(map[string]procCounters, len(p.procs)),
+		Logger: log.New(os.Stdout, "", log.LstdFlags),
+	}
+
+	for _, t := range p.procs {
+		target, err := newProcTarget(t)
+		if err != nil {
+			return nil, err
+		}
+		ps.targets = append(ps.targets, target)
+	}
+
+	if !p.enableLogger {
+		ps.SetOutput(io.Discard)
+	}
+
+	if _, _, err := ps.Update(ctx); err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+func newProcTarget(s string) (procTarget, error) {
+	var t procTarget
+
+	if role, rest, ok := strings.Cut(s, ":"); ok && !strings.Contains(role, "/") {
+		t.role, s = role, rest
+	} else {
+		t.role = "unknown"
+	}
+
+	if pid, err := strconv.Atoi(s); err == nil {
+		comm, err := os.ReadFile(filepath.Join("/proc", s, "comm"))
+		if err != nil {
+			return t, fmt.Errorf("process %d: %w", pid, err)
+		}
+		t.pid = pid
+		t.name = fmt.Sprintf("%s-%d", strings.TrimSpace(string(comm)), pid)
+		return t, nil
+	}
+
+	if !filepath.IsAbs(s) {
+		s = filepath.Join(cgroupRoot, s)
+	}
+	if _, err := os.Stat(filepath.Join(s, "cpu.stat")); err != nil {
+		return t, fmt.Errorf("cgroup %s: %w", s, err)
+	}
+	t.cgroup = s
+	t.name = filepath.Base(s)
+	return t, nil
+}
+
+// Update returns current resource usage by processes.
+func (s *procState) Update(ctx context.Context) (total ResourceUsage, per []ContainerStat, err error) {
+	per = make([]ContainerStat, 0, len(s.targets))
+	for _, t := range s.targets {
+		if err = ctx.Err(); err != nil {
+			return ResourceUsage{}, nil, err
+		}
+
+		var counters procCounters
+		if t.pid != 0 {
+			counters, err = pidCounters(t.pid)
+		} else {
+			counters, err = cgroupCounters(t.cgroup)
+		}
+		if err != nil {
+			return ResourceUsage{}, nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+
+		cur := ResourceUsage{Mem: counters.mem}
+		if prev, ok := s.prev[t.name]; ok {
+			counters.io.since(prev.io, &cur)
+			if dt := counters.io.at.Sub(prev.io.at).Seconds(); dt > 0 && counters.cpu >= prev.cpu {
+				// Share of all host CPUs like Docker reports it.
+				cur.CPU = (counters.cpu - prev.cpu) / dt / float64(runtime.NumCPU()) * 100
+			}
+		}
+		s.prev[t.name] = counters
+
+		total.add(cur)
+		per = append(per, ContainerStat{
+			Name:          t.name,
+			Role:          t.role,
+			ResourceUsage: cur,
+		})
+	}
+
+	return
+}
+
+// Run worker to periodically fetching resource usage by processes.
+func (s *procState) Run(ctx context.Context, cb StatCallback) {
+	runStats(ctx, s.Logger, s.per, s.Update, cb)
+}
+
+func pidCounters(pid int) (procCounters, error) {
+	var (
+		c   = procCounters{io: ioCounters{at: time.Now()}}
+		dir = filepath.Join("/proc", strconv.Itoa(pid))
+	)
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return c, err
+	}
+	c.cpu, err = parseProcStat(stat)
+	if err != nil {
+		return c, err
+	}
+
+	err = scanKeyValues(filepath.Join(dir, "status"), func(key string, value uint64) {
+		if key == "VmRSS:" {
+			c.mem = float64(value) / 1024
+		}
+	})
+	if err != nil {
+		return c, err
+	}
+
+	// Reading I/O of other users' processes requires privileges.
+	err = scanKeyValues(filepath.Join(dir, "io"), func(key string, value uint64) {
+		switch key {
+		case "read_bytes:":
+			c.io.blkRead = value
+		case "write_bytes:":
+			c.io.blkWrite = value
+		}
+	})
+	if err != nil && !errors.Is(err, os.ErrPermission) {
+		return c, err
+	}
+
+	c.io.netRx, c.io.netTx, err = netCounters(pid)
+	return c, err
+}
+
+func cgroupCounters(path string) (procCounters, error) {
+	c := procCounters{io: ioCounters{at: time.Now()}}
+
+	err := scanKeyValues(filepath.Join(path, "cpu.stat"), func(key string, value uint64) {
+		if key == "usage_usec" {
+			c.cpu = float64(value) / 1e6
+		}
+	})
+	if err != nil {
+		return c, err
+	}
+
+	current, err := os.ReadFile(filepath.Join(path, "memory.current"))
+	if err != nil {
+		return c, err
+	}
+	memStat, err := os.ReadFile(filepath.Join(path, "memory.stat"))
+	if err != nil {
+		return c, err
+	}
+	c.mem, err = parseCgroupMemory(current, memStat)
+	if err != nil {
+		return c, err
+	}
+
+	ioStat, err := os.ReadFile(filepath.Join(path, "io.stat"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c, err
+	}
+	parseIOStat(ioStat, &c.io)
+
+	// Network counters are taken from the namespace of any cgroup process.
+	procs, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+	if err != nil {
+		return c, err
+	}
+	if first, _, _ := strings.Cut(string(procs), "\n"); first != "" {
+		pid, err := strconv.Atoi(first)
+		if err != nil {
+			return c, fmt.Errorf("malformed cgroup.procs: %w", err)
+		}
+		c.io.netRx, c.io.netTx, err = netCounters(pid)
+		if err != nil {
+			return c, err
+		}
+	}
+
+	return c, nil
+}
+
+// netCounters returns bytes received and sent by all non-loopback interfaces
+// of the process network namespace. Processes sharing the host namespace get
+// zero counters, since host interfaces carry the traffic of all processes.
+func netCounters(pid int) (rx, tx uint64, err error) {
+	if hostNetNS(pid) {
+		return 0, 0, nil
+	}
+
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "net", "dev"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	return parseNetDev(f)
+}
+
+// parseNetDev sums bytes received and sent by non-loopback interfaces listed
+// in /proc/<pid>/net/dev.
+func parseNetDev(r io.Reader) (rx, tx uint64, err error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		iface, data, ok := strings.Cut(sc.Text(), ":")
+		if !ok || strings.TrimSpace(iface) == "lo" {
+			continue
+		}
+		fields := strings.Fields(data)
+		if len(fields) < 9 {
+			continue
+		}
+		r, _ := strconv.ParseUint(fields[0], 10, 64)
+		t, _ := strconv.ParseUint(fields[8], 10, 64)
+		rx += r
+		tx += t
+	}
+	return rx, tx, sc.Err()
+}
+
+// parseProcStat returns CPU time in seconds spent by the process in user and
+// kernel mode from /proc/<pid>/stat.
+func parseProcStat(stat []byte) (float64, error) {
+	// Command name may contain spaces and parentheses, so fields are counted
+	// after the last closing one.
+	idx := bytes.LastIndexByte(stat, ')')
+	if idx < 0 {
+		return 0, errors.New("malformed stat")
+	}
+	fields := strings.Fields(string(stat[idx+1:]))
+	if len(fields) < 13 {
+		return 0, errors.New("malformed stat")
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	return float64(utime+stime) / clockTicks, nil
+}
+
+// parseCgroupMemory returns memory usage in MB from cgroup memory.current and
+// memory.stat contents.
+func parseCgroupMemory(current, stat []byte) (float64, error) {
+	usage, err := strconv.ParseUint(strings.TrimSpace(string(current)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed memory.current: %w", err)
+	}
+	// Page cache is excluded like Docker does it.
+	err = parseKeyValues(bytes.NewReader(stat), func(key string, value uint64) {
+		if key == "inactive_file" && value < usage {
+			usage -= value
+		}
+	})
+	return float64(usage) / 1024 / 1024, err
+}
+
+// parseIOStat adds block I/O counters of all devices from cgroup io.stat.
+func parseIOStat(stat []byte, c *ioCounters) {
+	// Each line is "<major>:<minor> rbytes=N wbytes=N rios=N wios=N ...".
+	for _, line := range strings.Split(string(stat), "\n") {
+		fields := strings.Fields(line)
+		for _, f := range fields[min(1, len(fields)):] {
+			key, value, _ := strings.Cut(f, "=")
+			v, _ := strconv.ParseUint(value, 10, 64)
+			switch key {
+			case "rbytes":
+				c.blkRead += v
+			case "wbytes":
+				c.blkWrite += v
+			case "rios", "wios":
+				c.blkOps += v
+			}
+		}
+	}
+}
+
+// hostNetNS checks whether the process shares the network namespace with the
+// init process (or with the current one if it's not accessible).
+func hostNetNS(pid int) bool {
+	ns, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "ns", "net"))
+	if err != nil {
+		return false
+	}
+	host, err := os.Readlink("/proc/1/ns/net")
+	if err != nil {
+		host, err = os.Readlink("/proc/self/ns/net")
+		if err != nil {
+			return false
+		}
+	}
+	return ns == host
+}
+
+// scanKeyValues calls cb for every "key value" line of the file.
+func scanKeyValues(path string, cb func(key string, value uint64)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return parseKeyValues(f, cb)
+}
+
+// parseKeyValues calls cb for every "key value" line read from r.
+func parseKeyValues(r io.Reader, cb func(key string, value uint64)) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		cb(fields[0], v)
+	}
+	return sc.Err()
+}
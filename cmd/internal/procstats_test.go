@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProcStat(t *testing.T) {
+	tests := []struct {
+		name string
+		stat string
+		cpu  float64
+		ok   bool
+	}{
+		{"plain", "42 (neo-go) S 1 42 42 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 12 0 1000 100000 200", 3, true},
+		{"comm with spaces and parentheses", "42 (a) b (c) d) R 1 42 42 0 -1 4194560 100 0 0 0 1000 234 0 0", 12.34, true},
+		{"no comm", "42 neo-go S 1 42 42 0 -1 4194560 100 0 0 0 250 50", 0, false},
+		{"truncated", "42 (neo-go) S 1 42 42 0 -1 4194560 100 0 0 0 250", 0, false},
+	}
+	for _, tc := range tests {
+		cpu, err := parseProcStat([]byte(tc.stat))
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if cpu != tc.cpu {
+			t.Errorf("%s: expected %v CPU seconds, got %v", tc.name, tc.cpu, cpu)
+		}
+	}
+}
+
+func TestParseCgroupMemory(t *testing.T) {
+	const stat = `anon 1048576
+file 4194304
+inactive_file 2097152
+active_file 2097152
+`
+	tests := []struct {
+		name    string
+		current string
+		stat    string
+		mem     float64
+		ok      bool
+	}{
+		{"page cache excluded", "6291456\n", stat, 4, true},
+		{"cache exceeds usage", "1048576\n", stat, 1, true},
+		{"no stat", "1048576\n", "", 1, true},
+		{"malformed", "max\n", stat, 0, false},
+	}
+	for _, tc := range tests {
+		mem, err := parseCgroupMemory([]byte(tc.current), []byte(tc.stat))
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if tc.ok && mem != tc.mem {
+			t.Errorf("%s: expected %v MB, got %v", tc.name, tc.mem, mem)
+		}
+	}
+}
+
+func TestParseIOStat(t *testing.T) {
+	const stat = `8:0 rbytes=1024 wbytes=2048 rios=3 wios=4 dbytes=0 dios=0
+259:0 rbytes=100 wbytes=200 rios=1 wios=2 dbytes=0 dios=0
+
+`
+	c := ioCounters{blkRead: 1}
+	parseIOStat([]byte(stat), &c)
+	if c.blkRead != 1125 || c.blkWrite != 2248 || c.blkOps != 10 {
+		t.Fatalf("unexpected counters %+v", c)
+	}
+
+	c = ioCounters{}
+	parseIOStat(nil, &c)
+	if c != (ioCounters{}) {
+		t.Fatalf("unexpected counters %+v", c)
+	}
+}
+
+func TestParseNetDev(t *testing.T) {
+	const dev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  500000    1000    0    0    0     0          0         0   500000    1000    0    0    0     0       0          0
+  eth0: 1000 10 0 0 0 0 0 0 2000 20 0 0 0 0 0 0
+  eth1:300 3 0 0 0 0 0 0 400 4 0 0 0 0 0 0
+ short: 1 2 3
+`
+	rx, tx, err := parseNetDev(strings.NewReader(dev))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rx != 1300 || tx != 2400 {
+		t.Fatalf("expected 1300/2400 bytes, got %d/%d", rx, tx)
+	}
+}
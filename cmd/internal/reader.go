@@ -64,6 +64,27 @@ func ReadDump(from string) *Dump {
 	return &dump
 }
 
+// ReadDumpOptions reads options of transactions dump without reading transactions.
+func ReadDumpOptions(from string) (BenchOptions, error) {
+	var opts BenchOptions
+
+	in, err := os.Open(from)
+	if err != nil {
+		return opts, err
+	}
+	defer in.Close()
+
+	cp, err := gzip.NewReader(in)
+	if err != nil {
+		return opts, err
+	}
+	defer cp.Close()
+
+	rd := io.NewBinReaderFromIO(cp)
+	opts.DecodeBinary(rd)
+	return opts, rd.Err
+}
+
 // DecodeGoConfig decodes Golang node configuration from yaml file.
 func DecodeGoConfig(path string) (config.Config, error) {
 	var config = config.Config{}
@@ -1,12 +1,19 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/nspcc-dev/neo-go/pkg/core/block"
 )
 
 type (
@@ -19,17 +26,80 @@ type (
 		AverageRPS        float64
 		TPS               []tpsInfo
 		TPSPool           []tpsInfo
-		Stats             [][3]float64 // MillisecondsFromStart, CPU, Mem
+		Stats             []resStat
+		NodeStats         []nodeStats
+		Metrics           []metricSeries
+		Chaos             []chaosEvent
+		Consensus         []consensusInfo
 		DefaultMSPerBlock int
+		ValidatorsCount   int
+		GenRate           float64
+
+		start time.Time
+		tags  map[string]string
+		sinks []ResultSink
+	}
+
+	// nodeStats stores resource usage series of a single container.
+	nodeStats struct {
+		Name  string    `json:"name"`
+		Role  string    `json:"role"`
+		Stats []resStat `json:"stats"`
+	}
+
+	// metricSeries stores values of a single series scraped from node metrics.
+	metricSeries struct {
+		Endpoint string       `json:"endpoint"`
+		Name     string       `json:"name"`
+		Values   [][2]float64 `json:"values"` // MillisecondsFromStart, Value
+	}
+
+	// consensusInfo stores consensus timing of a single block.
+	consensusInfo struct {
+		Index     uint32 `json:"index"`
+		Timestamp uint64 `json:"timestamp"`
+		Primary   byte   `json:"primary"`
+		// View is a view number the block is accepted at, it's derived from
+		// the primary index, so it's known modulo validators count.
+		View int `json:"view"`
+		// DeltaTime is a time in milliseconds since the previous block.
+		DeltaTime uint64 `json:"delta_time"`
+		// Deviation is DeltaTime deviation from MillisecondsPerBlock.
+		Deviation int64 `json:"deviation"`
+	}
+
+	// consensusSummary describes consensus health over the benchmark.
+	consensusSummary struct {
+		Blocks       int
+		ViewChanges  int
+		SlowBlocks   int
+		MaxView      int
+		AvgDeviation float64
+		MaxDeviation int64
+	}
+
+	// chaosEvent stores chaos action performed during the benchmark.
+	chaosEvent struct {
+		MillisecondsFromStart float64 `json:"ms_from_start"`
+		Container             string  `json:"container"`
+		Action                string  `json:"action"`
+		// Error is empty if the action succeeded.
+		Error string `json:"error,omitempty"`
+	}
+
+	// resStat stores resource usage at some moment of benchmark.
+	resStat struct {
+		MillisecondsFromStart float64 `json:"ms_from_start"`
+		ResourceUsage
 	}
 
 	// tpsInfo stores information useful for counting TPS.
 	tpsInfo struct {
 		// DeltaTime is a time in milliseconds since the previous block timestamp.
-		DeltaTime uint64
+		DeltaTime uint64 `json:"delta_time"`
 		// TxCount is the number of transactions in block.
-		TxCount int
-		TPS     float64
+		TxCount int     `json:"tx_count"`
+		TPS     float64 `json:"tps"`
 	}
 
 	// Reporter interface.
@@ -39,7 +109,17 @@ type (
 		UpdateCnt(v int32)
 		UpdateRPS(v float64)
 		UpdateTPS(deltaTime uint64, txCount int, v float64)
-		UpdateRes(start time.Time, cpu, mem float64)
+		UpdateRes(start time.Time, total ResourceUsage, per []ContainerStat)
+		UpdateGenRate(v float64)
+		UpdateMetrics(start time.Time, endpoint string, values map[string]float64)
+		UpdateChaos(start time.Time, a ChaosAction, err error)
+		// UpdateConsensus adds consensus timing of the block accepted
+		// deltaTime milliseconds after the previous one.
+		UpdateConsensus(h *block.Header, deltaTime uint64)
+		// Summary returns overall benchmark results.
+		Summary() ResultSummary
+		// Push uploads results to all configured sinks.
+		Push(ctx context.Context) error
 	}
 
 	reportParams struct {
@@ -49,6 +129,9 @@ type (
 		rateLimit         int
 		timeLimit         time.Duration
 		defaultMSPerBlock int
+		validatorsCount   int
+		tags              map[string]string
+		sinks             []ResultSink
 	}
 
 	// ReportOption is an option type to configure reporter.
@@ -97,6 +180,29 @@ func ReportDefaultMSPerBlock(value int) ReportOption {
 	}
 }
 
+// ReportValidatorsCount sets the number of consensus nodes used to derive view
+// numbers of blocks.
+func ReportValidatorsCount(n int) ReportOption {
+	return func(p *reportParams) {
+		p.validatorsCount = n
+	}
+}
+
+// ReportTags sets tags (node versions, configuration, etc.) attached to the
+// results pushed to sinks.
+func ReportTags(tags map[string]string) ReportOption {
+	return func(p *reportParams) {
+		p.tags = tags
+	}
+}
+
+// ReportSinks sets sinks to push results to.
+func ReportSinks(sinks ...ResultSink) ReportOption {
+	return func(p *reportParams) {
+		p.sinks = sinks
+	}
+}
+
 // NewReporter creates reporter.
 func NewReporter(opts ...ReportOption) Reporter {
 	p := reportParams{
@@ -122,41 +228,103 @@ func NewReporter(opts ...ReportOption) Reporter {
 		Mutex:             new(sync.Mutex),
 		name:              fmt.Sprintf("%s / %d %s / %s", p.description, count, p.mode, p.timeLimit),
 		DefaultMSPerBlock: p.defaultMSPerBlock,
+		ValidatorsCount:   p.validatorsCount,
+		start:             time.Now(),
+		tags:              p.tags,
+		sinks:             p.sinks,
 	}
 }
 
-// WriteTo writes report to io.Writer.
-func (r *reporter) WriteTo(rw io.Writer) (int64, error) {
+// Push uploads results to all configured sinks.
+func (r *reporter) Push(ctx context.Context) error {
+	if len(r.sinks) == 0 {
+		return nil
+	}
+
+	r.Lock()
+	res := &Result{
+		Name:      r.name,
+		Tags:      r.tags,
+		Start:     r.start,
+		Summary:   r.summary(),
+		Blocks:    slices.Clone(r.TPS),
+		Stats:     slices.Clone(r.Stats),
+		Nodes:     slices.Clone(r.NodeStats),
+		Metrics:   slices.Clone(r.Metrics),
+		Chaos:     slices.Clone(r.Chaos),
+		Consensus: slices.Clone(r.Consensus),
+	}
+	r.Unlock()
+
+	var errs []error
+	for _, s := range r.sinks {
+		if err := s.Push(ctx, res); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resStatHeader describes columns of resource usage series.
+const resStatHeader = "MillisecondsFromStart, CPU, Mem, NetRx, NetTx, BlkRead, BlkWrite, IOPS"
+
+func (s resStat) writeTo(w io.Writer) (int, error) {
+	return fmt.Fprintf(w, "%0.3f, %0.3f%%, %0.3fMB, %0.3fMB, %0.3fMB, %0.3fMB, %0.3fMB, %0.3f\n",
+		s.MillisecondsFromStart, s.CPU, s.Mem, s.NetRx, s.NetTx, s.BlkRead, s.BlkWrite, s.IOPS)
+}
+
+// Summary returns overall benchmark results.
+func (r *reporter) Summary() ResultSummary {
 	r.Lock()
 	defer r.Unlock()
 
-	out := io.MultiWriter(rw, os.Stdout)
+	return r.summary()
+}
+
+// summary returns overall benchmark results, CPU, Mem and IOPS are averaged
+// while network and disk I/O are summed.
+func (r *reporter) summary() ResultSummary {
+	var (
+		sum               ResultSummary
+		overallblocksTime uint64
+	)
 
-	overallblocksTime := uint64(0)
-	txCount := 0 // != r.TxCount in case of early benchmark interrupt, because some transactions can still be in mempool
+	// != r.TxCount in case of early benchmark interrupt, because some transactions can still be in mempool
 	for i := range r.TPS {
 		overallblocksTime += r.TPS[i].DeltaTime
-		txCount += r.TPS[i].TxCount
+		sum.TxCount += r.TPS[i].TxCount
 	}
 
-	cpu := .0
 	for i := range r.Stats {
-		cpu += r.Stats[i][1]
-	}
+		sum.add(r.Stats[i].ResourceUsage)
+	}
+	resCount := float64(len(r.Stats))
+	sum.CPU /= resCount
+	sum.Mem /= resCount
+	sum.IOPS /= resCount
+
+	sum.RPS = r.AverageRPS
+	sum.ErrCount = r.ErrCount
+	sum.ErrRate = float64(r.ErrCount*100) / float64(int32(sum.TxCount)+r.ErrCount)
+	sum.TPS = float64(sum.TxCount) / float64(overallblocksTime) * 1000
+	sum.DefaultMSPerBlock = r.DefaultMSPerBlock
+	sum.GenRate = r.GenRate
+	return sum
+}
 
-	mem := .0
-	for i := range r.Stats {
-		mem += r.Stats[i][2]
-	}
+// WriteTo writes report to io.Writer.
+func (r *reporter) WriteTo(rw io.Writer) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	out := io.MultiWriter(rw, os.Stdout)
 
 	var (
 		num int
 		cnt int64
 		err error
 
-		resCount          = float64(len(r.Stats))
-		errRate           = float64(r.ErrCount*100) / float64(int32(txCount)+r.ErrCount)
-		defaultMSPerBlock = r.DefaultMSPerBlock
+		sum = r.summary()
 	)
 
 	if num, err = fmt.Fprintf(out, "%s\n\n", r.name); err != nil {
@@ -164,47 +332,62 @@ func (r *reporter) WriteTo(rw io.Writer) (int64, error) {
 	}
 	cnt += int64(num)
 
-	if _, err := fmt.Fprintf(out, "TXs ≈ %d\n", txCount); err != nil {
+	if _, err := fmt.Fprintf(out, "TXs ≈ %d\n", sum.TxCount); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if _, err := fmt.Fprintf(out, "RPS ≈ %0.3f\n", r.AverageRPS); err != nil {
+	if _, err := fmt.Fprintf(out, "RPS ≈ %0.3f\n", sum.RPS); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if _, err := fmt.Fprintf(out, "RPC Errors  ≈ %d / %0.3f%%\n", r.ErrCount, errRate); err != nil {
+	if _, err := fmt.Fprintf(out, "RPC Errors  ≈ %d / %0.3f%%\n", sum.ErrCount, sum.ErrRate); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if num, err = fmt.Fprintf(out, "TPS ≈ %0.3f\n", float64(txCount)/float64(overallblocksTime)*1000); err != nil {
+	if num, err = fmt.Fprintf(out, "TPS ≈ %0.3f\n", sum.TPS); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if num, err = fmt.Fprintf(out, "DefaultMSPerBlock = %d\n\n", defaultMSPerBlock); err != nil {
+	if num, err = fmt.Fprintf(out, "DefaultMSPerBlock = %d\n\n", sum.DefaultMSPerBlock); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if num, err = fmt.Fprintf(out, "CPU ≈ %0.3f%%\n", cpu/resCount); err != nil {
+	if num, err = fmt.Fprintf(out, "CPU ≈ %0.3f%%\n", sum.CPU); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if num, err = fmt.Fprintf(out, "Mem ≈ %0.3fMB\n\n", mem/resCount); err != nil {
+	if num, err = fmt.Fprintf(out, "Mem ≈ %0.3fMB\n", sum.Mem); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 
-	if _, err := fmt.Fprintln(out, "MillisecondsFromStart, CPU, Mem"); err != nil {
+	if num, err = fmt.Fprintf(out, "Net RX / TX ≈ %0.3fMB / %0.3fMB\n", sum.NetRx, sum.NetTx); err != nil {
+		return cnt + int64(num), err
+	}
+	cnt += int64(num)
+
+	if num, err = fmt.Fprintf(out, "Disk read / write ≈ %0.3fMB / %0.3fMB\n", sum.BlkRead, sum.BlkWrite); err != nil {
+		return cnt + int64(num), err
+	}
+	cnt += int64(num)
+
+	if num, err = fmt.Fprintf(out, "IOPS ≈ %0.3f\n\n", sum.IOPS); err != nil {
+		return cnt + int64(num), err
+	}
+	cnt += int64(num)
+
+	if _, err := fmt.Fprintln(out, resStatHeader); err != nil {
 		return cnt + int64(num), err
 	}
 	cnt += int64(num)
 	for i := range r.Stats {
-		if num, err = fmt.Fprintf(out, "%0.3f, %0.3f%%, %0.3fMB\n", r.Stats[i][0], r.Stats[i][1], r.Stats[i][2]); err != nil {
+		if num, err = r.Stats[i].writeTo(out); err != nil {
 			return cnt + int64(num), err
 		}
 		cnt += int64(num)
@@ -222,6 +405,89 @@ func (r *reporter) WriteTo(rw io.Writer) (int64, error) {
 		cnt += int64(num)
 	}
 
+	if r.GenRate > 0 {
+		if num, err = fmt.Fprintf(out, "\nGenerated on the fly ≈ %0.3f tx/s\n", r.GenRate); err != nil {
+			return cnt + int64(num), err
+		}
+		cnt += int64(num)
+	}
+
+	if len(r.NodeStats) != 0 {
+		if num, err = r.writeNodeStats(out); err != nil {
+			return cnt + int64(num), err
+		}
+		cnt += int64(num)
+	}
+
+	if len(r.Metrics) != 0 {
+		if num, err = r.writeMetrics(out); err != nil {
+			return cnt + int64(num), err
+		}
+		cnt += int64(num)
+	}
+
+	if len(r.Consensus) != 0 {
+		if num, err = r.writeConsensus(out); err != nil {
+			return cnt + int64(num), err
+		}
+		cnt += int64(num)
+	}
+
+	if len(r.Chaos) != 0 {
+		if num, err = r.writeChaos(out); err != nil {
+			return cnt + int64(num), err
+		}
+		cnt += int64(num)
+	}
+
+	return cnt, nil
+}
+
+func (r *reporter) writeNodeStats(out io.Writer) (int, error) {
+	var (
+		num int
+		cnt int
+		err error
+	)
+
+	if num, err = fmt.Fprintln(out, "\nNode, Role, CPU, MaxCPU, Mem, MaxMem, NetRx, NetTx, BlkRead, BlkWrite, IOPS"); err != nil {
+		return cnt + num, err
+	}
+	cnt += num
+
+	for i := range r.NodeStats {
+		var (
+			sum            ResourceUsage
+			maxCPU, maxMem float64
+		)
+		for _, st := range r.NodeStats[i].Stats {
+			sum.add(st.ResourceUsage)
+			maxCPU = max(maxCPU, st.CPU)
+			maxMem = max(maxMem, st.Mem)
+		}
+		n := float64(len(r.NodeStats[i].Stats))
+		if num, err = fmt.Fprintf(out, "%s, %s, %0.3f%%, %0.3f%%, %0.3fMB, %0.3fMB, %0.3fMB, %0.3fMB, %0.3fMB, %0.3fMB, %0.3f\n",
+			r.NodeStats[i].Name, r.NodeStats[i].Role, sum.CPU/n, maxCPU, sum.Mem/n, maxMem,
+			sum.NetRx, sum.NetTx, sum.BlkRead, sum.BlkWrite, sum.IOPS/n); err != nil {
+			return cnt + num, err
+		}
+		cnt += num
+	}
+
+	for i := range r.NodeStats {
+		if num, err = fmt.Fprintf(out, "\n%s (%s): %s\n", r.NodeStats[i].Name, r.NodeStats[i].Role, resStatHeader); err != nil {
+			return cnt + num, err
+		}
+		cnt += num
+
+		for _, st := range r.NodeStats[i].Stats {
+			if num, err = st.writeTo(out); err != nil {
+				return cnt + num, err
+			}
+			cnt += num
+		}
+	}
+
 	return cnt, nil
 }
 
@@ -288,9 +554,239 @@ func (r *reporter) UpdateTPS(deltaTime uint64, txCount int, v float64) {
 }
 
 // UpdateRes sets current resource usage by containers.
-func (r *reporter) UpdateRes(start time.Time, cpu, mem float64) {
+func (r *reporter) UpdateRes(start time.Time, total ResourceUsage, per []ContainerStat) {
+	r.Lock()
+	defer r.Unlock()
+
+	ms := float64(time.Since(start).Nanoseconds()) / 1000000
+	r.Stats = append(r.Stats, resStat{MillisecondsFromStart: ms, ResourceUsage: total})
+
+loop:
+	for i := range per {
+		stat := resStat{MillisecondsFromStart: ms, ResourceUsage: per[i].ResourceUsage}
+		for j := range r.NodeStats {
+			if r.NodeStats[j].Name == per[i].Name {
+				r.NodeStats[j].Stats = append(r.NodeStats[j].Stats, stat)
+				continue loop
+			}
+		}
+		r.NodeStats = append(r.NodeStats, nodeStats{
+			Name:  per[i].Name,
+			Role:  per[i].Role,
+			Stats: []resStat{stat},
+		})
+	}
+}
+
+// UpdateMetrics adds current values of node metrics scraped from the endpoint.
+func (r *reporter) UpdateMetrics(start time.Time, endpoint string, values map[string]float64) {
+	r.Lock()
+	defer r.Unlock()
+
+	ms := float64(time.Since(start).Nanoseconds()) / 1000000
+
+	names := make([]string, 0, len(values))
+	for name, v := range values {
+		// Not a number values can't be pushed to sinks.
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+loop:
+	for _, name := range names {
+		v := [2]float64{ms, values[name]}
+		for j := range r.Metrics {
+			if r.Metrics[j].Endpoint == endpoint && r.Metrics[j].Name == name {
+				r.Metrics[j].Values = append(r.Metrics[j].Values, v)
+				continue loop
+			}
+		}
+		r.Metrics = append(r.Metrics, metricSeries{
+			Endpoint: endpoint,
+			Name:     name,
+			Values:   [][2]float64{v},
+		})
+	}
+}
+
+// UpdateConsensus adds consensus timing of the block. View number is derived
+// from the primary index: primary of view v at height h is (h - v) mod N.
+func (r *reporter) UpdateConsensus(h *block.Header, deltaTime uint64) {
 	r.Lock()
 	defer r.Unlock()
 
-	r.Stats = append(r.Stats, [3]float64{float64(time.Since(start).Nanoseconds()) / 1000000, cpu, mem})
+	info := consensusInfo{
+		Index:     h.Index,
+		Timestamp: h.Timestamp,
+		Primary:   h.PrimaryIndex,
+		DeltaTime: deltaTime,
+	}
+	if n := r.ValidatorsCount; n > 1 {
+		info.View = (int(h.Index%uint32(n)) - int(h.PrimaryIndex) + n) % n
+	}
+	if r.DefaultMSPerBlock > 0 {
+		info.Deviation = int64(deltaTime) - int64(r.DefaultMSPerBlock)
+	}
+	r.Consensus = append(r.Consensus, info)
+}
+
+// UpdateChaos adds chaos action performed during the benchmark.
+func (r *reporter) UpdateChaos(start time.Time, a ChaosAction, err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	ev := chaosEvent{
+		MillisecondsFromStart: float64(time.Since(start).Nanoseconds()) / 1000000,
+		Container:             a.Container,
+		Action:                a.Action,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	r.Chaos = append(r.Chaos, ev)
+}
+
+// UpdateGenRate sets current rate of transactions generation.
+func (r *reporter) UpdateGenRate(v float64) {
+	if v <= 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.GenRate = v
+}
+
+func (r *reporter) writeMetrics(out io.Writer) (int, error) {
+	var (
+		num int
+		cnt int
+		err error
+	)
+
+	if num, err = fmt.Fprintln(out, "\nEndpoint, Metric, Min, Max, Last, Delta"); err != nil {
+		return cnt + num, err
+	}
+	cnt += num
+
+	for _, m := range r.Metrics {
+		var (
+			first = m.Values[0][1]
+			last  = m.Values[len(m.Values)-1][1]
+			lo    = first
+			hi    = first
+		)
+		for _, v := range m.Values {
+			lo = min(lo, v[1])
+			hi = max(hi, v[1])
+		}
+		if num, err = fmt.Fprintf(out, "%s, %s, %g, %g, %g, %g\n", m.Endpoint, m.Name, lo, hi, last, last-first); err != nil {
+			return cnt + num, err
+		}
+		cnt += num
+	}
+
+	for _, m := range r.Metrics {
+		if num, err = fmt.Fprintf(out, "\n%s %s: MillisecondsFromStart, Value\n", m.Endpoint, m.Name); err != nil {
+			return cnt + num, err
+		}
+		cnt += num
+
+		for _, v := range m.Values {
+			if num, err = fmt.Fprintf(out, "%0.3f, %g\n", v[0], v[1]); err != nil {
+				return cnt + num, err
+			}
+			cnt += num
+		}
+	}
+
+	return cnt, nil
+}
+
+func (r *reporter) writeChaos(out io.Writer) (int, error) {
+	var (
+		num int
+		cnt int
+		err error
+	)
+
+	if num, err = fmt.Fprintln(out, "\nChaos: MillisecondsFromStart, Container, Action, Error"); err != nil {
+		return cnt + num, err
+	}
+	cnt += num
+
+	for _, ev := range r.Chaos {
+		if num, err = fmt.Fprintf(out, "%0.3f, %s, %s, %s\n", ev.MillisecondsFromStart, ev.Container, ev.Action,
+			strings.ReplaceAll(ev.Error, "\n", " ")); err != nil {
+			return cnt + num, err
+		}
+		cnt += num
+	}
+
+	return cnt, nil
+}
+
+// consensusSummary returns consensus health over the benchmark. Blocks accepted
+// at non-zero view or later than twice MillisecondsPerBlock (the first view
+// change timeout of dBFT) mean that consensus had to change views.
+func (r *reporter) consensusSummary() consensusSummary {
+	var (
+		sum consensusSummary
+		dev int64
+	)
+	for _, c := range r.Consensus {
+		sum.Blocks++
+		if c.View > 0 {
+			sum.ViewChanges++
+		}
+		if r.DefaultMSPerBlock > 0 && c.DeltaTime > 2*uint64(r.DefaultMSPerBlock) {
+			sum.SlowBlocks++
+		}
+		sum.MaxView = max(sum.MaxView, c.View)
+		sum.MaxDeviation = max(sum.MaxDeviation, c.Deviation)
+		dev += c.Deviation
+	}
+	if sum.Blocks != 0 {
+		sum.AvgDeviation = float64(dev) / float64(sum.Blocks)
+	}
+	return sum
+}
+
+func (r *reporter) writeConsensus(out io.Writer) (int, error) {
+	var (
+		num int
+		cnt int
+		err error
+
+		sum = r.consensusSummary()
+	)
+
+	if num, err = fmt.Fprintln(out, "\nConsensus: Blocks, ViewChanges, SlowBlocks, MaxView, AvgDeviation, MaxDeviation"); err != nil {
+		return cnt + num, err
+	}
+	cnt += num
+
+	if num, err = fmt.Fprintf(out, "%d, %d, %d, %d, %0.3fms, %dms\n", sum.Blocks, sum.ViewChanges, sum.SlowBlocks,
+		sum.MaxView, sum.AvgDeviation, sum.MaxDeviation); err != nil {
+		return cnt + num, err
+	}
+	cnt += num
+
+	if num, err = fmt.Fprintln(out, "\nConsensus: Index, Primary, View, DeltaTime, Deviation"); err != nil {
+		return cnt + num, err
+	}
+	cnt += num
+
+	for _, c := range r.Consensus {
+		if num, err = fmt.Fprintf(out, "%d, %d, %d, %d, %d\n", c.Index, c.Primary, c.View, c.DeltaTime, c.Deviation); err != nil {
+			return cnt + num, err
+		}
+		cnt += num
+	}
+
+	return cnt, nil
 }
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nspcc-dev/neo-go/pkg/core/block"
+)
+
+func TestUpdateConsensus(t *testing.T) {
+	tests := []struct {
+		validators int
+		index      uint32
+		primary    byte
+		delta      uint64
+		view       int
+		deviation  int64
+	}{
+		{4, 8, 0, 1000, 0, 0},
+		{4, 9, 1, 1000, 0, 0},
+		{4, 9, 2, 3000, 3, 2000},
+		{4, 10, 0, 2000, 2, 1000},
+		{7, 13, 5, 900, 1, -100},
+		{7, 14, 1, 4000, 6, 3000},
+		{1, 5, 0, 1500, 0, 500},
+	}
+	for _, tc := range tests {
+		rep := NewReporter(ReportValidatorsCount(tc.validators), ReportDefaultMSPerBlock(1000)).(*reporter)
+		rep.UpdateConsensus(&block.Header{Index: tc.index, PrimaryIndex: tc.primary, Timestamp: 1}, tc.delta)
+
+		c := rep.Consensus[0]
+		if c.View != tc.view || c.Deviation != tc.deviation || c.Primary != tc.primary || c.Index != tc.index {
+			t.Errorf("%d validators, block %d, primary %d: unexpected %+v", tc.validators, tc.index, tc.primary, c)
+		}
+	}
+}
+
+func TestReporterNodeStats(t *testing.T) {
+	rep := NewReporter().(*reporter)
+	for range 2 {
+		rep.UpdateRes(time.Now(), ResourceUsage{}, []ContainerStat{
+			{Name: "neo_go_node_one", Role: "validator", ResourceUsage: ResourceUsage{CPU: 10}},
+			{Name: "neo_go_node", Role: "rpc", ResourceUsage: ResourceUsage{CPU: 20}},
+		})
+	}
+	if len(rep.NodeStats) != 2 || len(rep.NodeStats[0].Stats) != 2 || len(rep.NodeStats[1].Stats) != 2 ||
+		rep.NodeStats[0].Role != "validator" || rep.NodeStats[1].Role != "rpc" {
+		t.Fatalf("unexpected node stats %+v", rep.NodeStats)
+	}
+
+	rep.NodeStats = []nodeStats{{
+		Name: "neo_go_node_one",
+		Role: "validator",
+		Stats: []resStat{
+			{MillisecondsFromStart: 1000, ResourceUsage: ResourceUsage{CPU: 10, Mem: 100, NetRx: 1, NetTx: 2, BlkRead: 3, BlkWrite: 4, IOPS: 10}},
+			{MillisecondsFromStart: 2000, ResourceUsage: ResourceUsage{CPU: 30, Mem: 300, NetRx: 1, NetTx: 2, BlkRead: 3, BlkWrite: 4, IOPS: 30}},
+		},
+	}, {
+		Name:  "neo_go_node",
+		Role:  "unknown",
+		Stats: []resStat{{MillisecondsFromStart: 1000, ResourceUsage: ResourceUsage{CPU: 5, Mem: 50}}},
+	}}
+
+	var buf bytes.Buffer
+	n, err := rep.writeNodeStats(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `
+Node, Role, CPU, MaxCPU, Mem, MaxMem, NetRx, NetTx, BlkRead, BlkWrite, IOPS
+neo_go_node_one, validator, 20.000%, 30.000%, 200.000MB, 300.000MB, 2.000MB, 4.000MB, 6.000MB, 8.000MB, 20.000
+neo_go_node, unknown, 5.000%, 5.000%, 50.000MB, 50.000MB, 0.000MB, 0.000MB, 0.000MB, 0.000MB, 0.000
+
+neo_go_node_one (validator): MillisecondsFromStart, CPU, Mem, NetRx, NetTx, BlkRead, BlkWrite, IOPS
+1000.000, 10.000%, 100.000MB, 1.000MB, 2.000MB, 3.000MB, 4.000MB, 10.000
+2000.000, 30.000%, 300.000MB, 1.000MB, 2.000MB, 3.000MB, 4.000MB, 30.000
+
+neo_go_node (unknown): MillisecondsFromStart, CPU, Mem, NetRx, NetTx, BlkRead, BlkWrite, IOPS
+1000.000, 5.000%, 50.000MB, 0.000MB, 0.000MB, 0.000MB, 0.000MB, 0.000
+`
+	if buf.String() != want || n != len(want) {
+		t.Fatalf("expected %d bytes:%s\ngot %d bytes:%s", len(want), want, n, buf.String())
+	}
+}
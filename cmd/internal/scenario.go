@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Scenario is a declarative description of the benchmark, every field
+	// corresponds to a bench flag, unset fields keep flag values.
+	Scenario struct {
+		Description string            `yaml:"description"`
+		Mode        string            `yaml:"mode"`
+		Endpoints   ScenarioEndpoints `yaml:"endpoints"`
+		Load        ScenarioLoad      `yaml:"load"`
+		Matrix      ScenarioMatrix    `yaml:"matrix"`
+		Workload    ScenarioWorkload  `yaml:"workload"`
+		// Dump is a path to transactions dump, relative paths are resolved
+		// against scenario file directory.
+		Dump   string          `yaml:"dump"`
+		Stats  ScenarioStats   `yaml:"stats"`
+		Chaos  []ScenarioChaos `yaml:"chaos"`
+		Report ScenarioReport  `yaml:"report"`
+	}
+
+	// ScenarioEndpoints are node endpoints used by the benchmark.
+	ScenarioEndpoints struct {
+		RPC     []string `yaml:"rpc"`
+		Metrics []string `yaml:"metrics"`
+		Pprof   []string `yaml:"pprof"`
+	}
+
+	// ScenarioLoad is a load profile of the benchmark.
+	ScenarioLoad struct {
+		Workers        *int           `yaml:"workers"`
+		Rate           *int           `yaml:"rate"`
+		Duration       *time.Duration `yaml:"duration"`
+		Concurrent     *int           `yaml:"concurrent"`
+		RequestTimeout *time.Duration `yaml:"request_timeout"`
+	}
+
+	// ScenarioMatrix describes combinations of load profiles run sequentially.
+	ScenarioMatrix struct {
+		Modes         []string       `yaml:"modes"`
+		Workers       []int          `yaml:"workers"`
+		Rates         []int          `yaml:"rates"`
+		Cooldown      *time.Duration `yaml:"cooldown"`
+		SettleTimeout *time.Duration `yaml:"settle_timeout"`
+	}
+
+	// ScenarioWorkload describes transactions generated on the fly and keys
+	// used to prepare the chain.
+	ScenarioWorkload struct {
+		Type     *string `yaml:"type"`
+		Count    *int    `yaml:"count"`
+		From     *int    `yaml:"from"`
+		To       *int    `yaml:"to"`
+		Signers  *int    `yaml:"signers"`
+		Scope    *string `yaml:"scope"`
+		FromDist *string `yaml:"from_dist"`
+		ToDist   *string `yaml:"to_dist"`
+		Seed     *uint64 `yaml:"seed"`
+		Buffer   *uint64 `yaml:"buffer"`
+		Vote     *bool   `yaml:"vote"`
+		// Keys is a path to keys config, relative paths are resolved against
+		// scenario file directory.
+		Keys string `yaml:"keys"`
+	}
+
+	// ScenarioStats configures resource usage, metrics and profiles collection.
+	ScenarioStats struct {
+		Disable   *bool           `yaml:"disable"`
+		Processes []string        `yaml:"processes"`
+		Metrics   []string        `yaml:"metrics"`
+		Pprof     ScenarioProfile `yaml:"pprof"`
+	}
+
+	// ScenarioProfile configures profiles captured from pprof endpoints.
+	ScenarioProfile struct {
+		At          []time.Duration `yaml:"at"`
+		Profiles    []string        `yaml:"profiles"`
+		CPUDuration *time.Duration  `yaml:"cpu_duration"`
+	}
+
+	// ScenarioChaos is an action performed on node container during the run.
+	ScenarioChaos struct {
+		At        time.Duration `yaml:"at"`
+		Action    string        `yaml:"action"`
+		Container string        `yaml:"container"`
+	}
+
+	// ScenarioReport configures where the results go.
+	ScenarioReport struct {
+		Out         string            `yaml:"out"`
+		Exporter    string            `yaml:"exporter"`
+		Influx      []string          `yaml:"influx"`
+		InfluxToken string            `yaml:"influx_token"`
+		Webhook     []string          `yaml:"webhook"`
+		Tags        map[string]string `yaml:"tags"`
+	}
+)
+
+// LoadScenario reads scenario from the given path, unknown fields are treated
+// as errors.
+func LoadScenario(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read scenario: %w", err)
+	}
+	defer f.Close()
+
+	var sc Scenario
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&sc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not decode scenario %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&sc.Dump, &sc.Workload.Keys} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+
+	return &sc, nil
+}
+
+// Apply sets flags not changed explicitly to the scenario values.
+func (s *Scenario) Apply(fs *pflag.FlagSet) error {
+	values := s.flagValues()
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if fs.Changed(name) {
+			continue
+		}
+		for _, v := range values[name] {
+			if err := fs.Set(name, v); err != nil {
+				errs = append(errs, fmt.Errorf("scenario: %s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flagValues returns values of set scenario fields keyed by flag names.
+func (s *Scenario) flagValues() map[string][]string {
+	res := make(map[string][]string)
+	str := func(name, v string) {
+		if v != "" {
+			res[name] = []string{v}
+		}
+	}
+	list := func(name string, v []string) {
+		if len(v) != 0 {
+			res[name] = v
+		}
+	}
+	ptr := func(name string, v fmt.Stringer) {
+		if v != nil {
+			res[name] = []string{v.String()}
+		}
+	}
+
+	str("desc", s.Description)
+	str("mode", s.Mode)
+	str("in", s.Dump)
+
+	list("rpcAddress", s.Endpoints.RPC)
+	list("metrics", s.Endpoints.Metrics)
+	list("pprof", s.Endpoints.Pprof)
+
+	ptr("workers", optional(s.Load.Workers))
+	ptr("rateLimit", optional(s.Load.Rate))
+	ptr("timeLimit", optional(s.Load.Duration))
+	ptr("concurrent", optional(s.Load.Concurrent))
+	ptr("request_timeout", optional(s.Load.RequestTimeout))
+
+	list("matrix-mode", s.Matrix.Modes)
+	for _, w := range s.Matrix.Workers {
+		res["matrix-workers"] = append(res["matrix-workers"], strconv.Itoa(w))
+	}
+	for _, r := range s.Matrix.Rates {
+		res["matrix-rate"] = append(res["matrix-rate"], strconv.Itoa(r))
+	}
+	ptr("cooldown", optional(s.Matrix.Cooldown))
+	ptr("settle-timeout", optional(s.Matrix.SettleTimeout))
+
+	w := s.Workload
+	ptr("gen-type", optional(w.Type))
+	ptr("gen-count", optional(w.Count))
+	ptr("gen-from", optional(w.From))
+	ptr("gen-to", optional(w.To))
+	ptr("gen-signers", optional(w.Signers))
+	ptr("gen-scope", optional(w.Scope))
+	ptr("gen-from-dist", optional(w.FromDist))
+	ptr("gen-to-dist", optional(w.ToDist))
+	ptr("gen-seed", optional(w.Seed))
+	ptr("gen-buffer", optional(w.Buffer))
+	ptr("vote", optional(w.Vote))
+	str("keys", w.Keys)
+
+	ptr("disable-stats", optional(s.Stats.Disable))
+	list("stats-proc", s.Stats.Processes)
+	list("metrics-name", s.Stats.Metrics)
+	for _, at := range s.Stats.Pprof.At {
+		res["pprof-at"] = append(res["pprof-at"], at.String())
+	}
+	list("pprof-profiles", s.Stats.Pprof.Profiles)
+	ptr("pprof-cpu-duration", optional(s.Stats.Pprof.CPUDuration))
+
+	for _, c := range s.Chaos {
+		res["chaos"] = append(res["chaos"], ChaosAction{At: c.At, Action: c.Action, Container: c.Container}.String())
+	}
+
+	str("out", s.Report.Out)
+	str("exporter", s.Report.Exporter)
+	list("sink-influx", s.Report.Influx)
+	str("sink-influx-token", s.Report.InfluxToken)
+	list("sink-webhook", s.Report.Webhook)
+	for k, v := range s.Report.Tags {
+		res["result-tag"] = append(res["result-tag"], k+"="+v)
+	}
+	sort.Strings(res["result-tag"])
+
+	return res
+}
+
+type optionalValue[T any] struct{ v T }
+
+func (o optionalValue[T]) String() string {
+	return fmt.Sprint(o.v)
+}
+
+// optional returns nil Stringer for nil pointer, so that unset fields could be
+// skipped.
+func optional[T any](p *T) fmt.Stringer {
+	if p == nil {
+		return nil
+	}
+	return optionalValue[T]{*p}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const testScenario = `
+description: NEO 4x1
+mode: rate
+endpoints:
+  rpc: [node1:20331, node2:20331]
+load:
+  workers: 30
+  duration: 5m
+workload:
+  count: 100
+  keys: keys.yml
+chaos:
+  - at: 1m
+    action: pause
+    container: node
+report:
+  tags:
+    release: 0.117.0
+    commit: abc
+`
+
+func newScenarioFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("desc", "unknown benchmark", "")
+	fs.String("mode", "wrk", "")
+	fs.StringArray("rpcAddress", nil, "")
+	fs.Int("workers", 1, "")
+	fs.Duration("timeLimit", time.Minute, "")
+	fs.Int("gen-count", 0, "")
+	fs.String("keys", "", "")
+	fs.StringArray("chaos", nil, "")
+	fs.StringArray("result-tag", nil, "")
+	return fs
+}
+
+func TestScenarioApply(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scenario.yml")
+	if err := os.WriteFile(path, []byte(testScenario), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := LoadScenario(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := newScenarioFlags()
+	if err := fs.Parse([]string{"--workers", "10"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Apply(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"desc", []string{"NEO 4x1"}},
+		{"mode", []string{"rate"}},
+		{"rpcAddress", []string{"node1:20331", "node2:20331"}},
+		// Explicit flags take precedence over the scenario.
+		{"workers", []string{"10"}},
+		{"timeLimit", []string{"5m0s"}},
+		{"gen-count", []string{"100"}},
+		{"keys", []string{filepath.Join(dir, "keys.yml")}},
+		{"chaos", []string{"1m0s:pause:node"}},
+		{"result-tag", []string{"commit=abc", "release=0.117.0"}},
+	}
+	for _, tc := range tests {
+		var got []string
+		if v, err := fs.GetStringArray(tc.name); err == nil {
+			got = v
+		} else {
+			got = []string{fs.Lookup(tc.name).Value.String()}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestScenarioApplyInvalid(t *testing.T) {
+	count := -1
+	sc := &Scenario{Mode: "rate", Workload: ScenarioWorkload{Count: &count}, Dump: "dump.txs"}
+
+	// Other values are applied even if some flag can't be set.
+	fs := newScenarioFlags()
+	if err := sc.Apply(fs); err == nil {
+		t.Fatal("expected error for the flag that isn't defined")
+	}
+	if v, _ := fs.GetString("mode"); v != "rate" {
+		t.Fatalf("mode isn't applied: %s", v)
+	}
+	if v, _ := fs.GetInt("gen-count"); v != count {
+		t.Fatalf("gen-count isn't applied: %d", v)
+	}
+}
+
+func TestLoadScenarioUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.yml")
+	if err := os.WriteFile(path, []byte("mode: rate\nworkers: 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadScenario(path); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
@@ -57,6 +58,10 @@ func InitSettings() *viper.Viper {
 
 	help := flags.BoolP("help", "h", false, "Show usage message.")
 
+	scenario := flags.StringP("scenario", "s", "",
+		"``Path to YAML scenario file with benchmark parameters, flags override its values.\n"+
+			"Example: -s ./scenarios/neo-rate.yml")
+
 	desc := flags.StringP("desc", "d", "unknown benchmark", "Benchmark description.")
 
 	out := flags.StringP("out", "o", "report.log", "Path where report would be written.")
@@ -80,6 +85,21 @@ func InitSettings() *viper.Viper {
 		"Number of used cpu cores."+
 			"Example: -c 4 --concurrent 8")
 
+	matrixModes := flags.StringSlice("matrix-mode", nil,
+		"``Benchmark modes of matrix runs, --mode is used if not set.\n"+
+			"Every combination of matrix modes, workers and rates is run sequentially against the same chain,\n"+
+			"reports of runs are written next to the combined summary written to --out.\n"+
+			"Example: --matrix-mode wrk,rate")
+	matrixWorkers := flags.IntSlice("matrix-workers", nil,
+		"``Numbers of workers of matrix runs, --workers is used if not set.\n"+
+			"Example: --matrix-workers 10,30,100")
+	matrixRates := flags.IntSlice("matrix-rate", nil,
+		"``Rate limits of matrix runs in rate mode, --rateLimit is used if not set.\n"+
+			"Example: --matrix-rate 1000,5000")
+	cooldown := flags.Duration("cooldown", 10*time.Second, "Pause between matrix runs after the chain is settled.")
+	settleTimeout := flags.Duration("settle-timeout", 2*time.Minute,
+		"Time limit to wait for the empty mempool and the next block between matrix runs.")
+
 	rpcAddresses := flags.StringArrayP("rpcAddress", "a", []string{"127.0.0.1:20331"},
 		"``RPC addresses for RPC calls to test nodes.\n"+
 			"You can specify multiple addresses.\n"+
@@ -94,8 +114,70 @@ func InitSettings() *viper.Viper {
 		"``Path to input file to load transactions.\n"+
 			"Example: -i ./dump.txs --in /path/to/import/transactions")
 
+	flags.String("gen-type", NEOTransfer,
+		"``Type of transactions generated on the fly if input file isn't specified.\n"+
+			"Example: --gen-type neo --gen-type gas --gen-type nep17")
+	genCount := flags.Int("gen-count", 1_000_000, "Number of transactions generated on the fly.")
+	flags.Int("gen-from", 1, "Number of senders of transactions generated on the fly.")
+	flags.Int("gen-to", 1, "Number of receivers of transactions generated on the fly.")
+	flags.Int("gen-signers", 1, "Number of signers of transactions generated on the fly.")
+	flags.String("gen-scope", ScopeCalledByEntry,
+		"``Witness scope of transactions generated on the fly.\n"+
+			"Possible values: entry, contracts, groups, rules, global")
+	flags.String("gen-from-dist", DistRoundRobin,
+		"``Distribution of senders of transactions generated on the fly.\n"+
+			"Possible values: roundrobin, uniform, zipf")
+	flags.String("gen-to-dist", DistRoundRobin,
+		"``Distribution of receivers of transactions generated on the fly.\n"+
+			"Possible values: roundrobin, uniform, zipf, fresh")
+	flags.Uint64("gen-seed", 0, "Seed for transactions generated on the fly, random if not set.")
+	genBuffer := flags.Uint64("gen-buffer", 100_000, "Number of transactions generated on the fly to buffer before sending.")
+
 	flags.BoolP("vote", "", false, "Vote before the bench.")
+	flags.String("keys", "",
+		"``Path to keys config with committee and the first sender keys,\n"+
+			"default keys of 1, 4 and 7 nodes networks are used if not specified.")
 	flags.BoolP("disable-stats", "", false, "Disable memory and CPU usage statistics collection.")
+	statsProcs := flags.StringArray("stats-proc", nil,
+		"``Process PID or cgroup v2 path to collect statistics of instead of Docker containers,\n"+
+			"optionally prefixed with the node role. You can specify multiple targets.\n"+
+			"Example: --stats-proc validator:/sys/fs/cgroup/system.slice/neo-go.service --stats-proc rpc:1234")
+	metrics := flags.StringArray("metrics", nil,
+		"``Prometheus endpoint of node to scrape metrics from into the report.\n"+
+			"You can specify multiple endpoints.\n"+
+			"Example: --metrics 127.0.0.1:40001 --metrics http://127.0.0.1:40002/metrics")
+	metricsNames := flags.StringArray("metrics-name", DefaultMetrics,
+		"``Name of scraped metric to record, '*' suffix matches metrics by prefix.\n"+
+			"Example: --metrics-name neogo_current_block_height --metrics-name 'dbft_*'")
+
+	pprofs := flags.StringArray("pprof", nil,
+		"``pprof endpoint of node to capture profiles from, profiles are stored next to the report.\n"+
+			"You can specify multiple endpoints.\n"+
+			"Example: --pprof 127.0.0.1:30001 --pprof 127.0.0.1:30002")
+	pprofAt := flags.DurationSlice("pprof-at", nil,
+		"``Time since the benchmark start to capture profiles at, the middle of the run if not set.\n"+
+			"Example: --pprof-at 1m,2m")
+	pprofProfiles := flags.StringSlice("pprof-profiles", DefaultProfiles, "Kinds of captured profiles.")
+	flags.Duration("pprof-cpu-duration", 10*time.Second, "Duration of CPU profiling.")
+	chaos := flags.StringArray("chaos", nil,
+		"``Action performed on node container via Docker API at the given time since the benchmark start\n"+
+			"in <at>:<action>:<container> format, possible actions: "+strings.Join(chaosActions, ", ")+".\n"+
+			"You can specify multiple actions.\n"+
+			"Example: --chaos 1m:kill:neo_go_node_four --chaos 2m:start:neo_go_node_four")
+	flags.String("exporter", "",
+		"``Address to serve live benchmark metrics on in Prometheus format, disabled if not set.\n"+
+			"Example: --exporter :9100")
+
+	sinkInflux := flags.StringArray("sink-influx", nil,
+		"``InfluxDB write endpoint to push results to in line protocol.\n"+
+			"Example: --sink-influx 'http://127.0.0.1:8086/api/v2/write?org=neo&bucket=bench'")
+	flags.String("sink-influx-token", "", "InfluxDB API token, NEOBENCH_INFLUX_TOKEN environment variable is used if not set.")
+	sinkWebhook := flags.StringArray("sink-webhook", nil,
+		"``URL to POST results to as JSON.\n"+
+			"Example: --sink-webhook http://127.0.0.1:8080/results")
+	resultTags := flags.StringArray("result-tag", nil,
+		"``Tag attached to results pushed to sinks in addition to node version and configuration.\n"+
+			"Example: --result-tag git_commit=abcdef0 --result-tag ci_job=42")
 
 	if err := v.BindPFlags(flags); err != nil {
 		panic(err)
@@ -109,48 +191,181 @@ func InitSettings() *viper.Viper {
 		panic(err)
 	}
 
+	if *help {
+		exit(0)
+	}
+
+	if *scenario != "" {
+		sc, err := LoadScenario(*scenario)
+		if err != nil {
+			exit(2, err.Error())
+		}
+		if err := sc.Apply(flags); err != nil {
+			exit(2, err.Error())
+		}
+	}
+
+	// All problems are reported at once.
+	var problems []string
+	fail := func(msg string) {
+		problems = append(problems, msg)
+	}
+
 	if err := validateImport(input); err != nil {
-		exit(2, err.Error())
+		fail(err.Error())
 	}
 
-	switch {
-	case help != nil && *help:
-		exit(0)
-	case reqTimeout == nil || *reqTimeout < 0:
-		exit(2, "Request timeout could not be negative value.")
-	case out == nil || *out == "":
-		exit(2, "Report path could not be empty.")
-	case desc == nil || *desc == "":
-		exit(2, "Benchmark description could not be empty.")
-	case mode == nil || *mode == "":
-		exit(2, "Benchmark mode could not be empty.")
-	case rpcAddresses == nil || len(*rpcAddresses) == 0:
-		exit(2, "RPC addresses could not be empty.")
-	case concurrent == nil || *concurrent <= 0:
-		exit(2, "CPUs could not be empty or negative value.")
-	case timeLimit == nil || *timeLimit <= 0:
-		exit(2, "Time limit could not be empty or negative value.")
+	if *reqTimeout < 0 {
+		fail("Request timeout could not be negative value.")
+	}
+	if *out == "" {
+		fail("Report path could not be empty.")
+	}
+	if *desc == "" {
+		fail("Benchmark description could not be empty.")
+	}
+	if len(*rpcAddresses) == 0 {
+		fail("RPC addresses could not be empty.")
+	}
+	if *concurrent <= 0 {
+		fail("CPUs could not be empty or negative value.")
+	}
+	if *timeLimit <= 0 {
+		fail("Time limit could not be empty or negative value.")
+	}
+
+	if *input == "" {
+		switch {
+		case *genCount <= 0:
+			fail("Number of generated transactions could not be empty or negative value.")
+		case *genBuffer == 0:
+			fail("Generated transactions buffer could not be empty.")
+		default:
+			// Senders don't matter for validation, keys are loaded later.
+			first, err := keys.NewPrivateKey()
+			if err != nil {
+				exit(2, err.Error())
+			}
+			if _, err := NewGenerateOptions(v, first); err != nil {
+				fail(err.Error())
+			}
+		}
 	}
 
 	switch BenchMode(*mode) {
 	case ModeWorker:
-		switch {
-		case workers == nil || *workers <= 0:
-			exit(2, "Workers count could not be empty or negative value")
+		if *workers <= 0 {
+			fail("Workers count could not be empty or negative value")
 		}
 	case ModeRate:
-		switch {
-		case rateLimit == nil || *rateLimit <= 0:
-			exit(2, "Rate limit (QPS) could not be empty or negative value")
+		if *rateLimit <= 0 {
+			fail("Rate limit (QPS) could not be empty or negative value")
 		}
+	case "":
+		fail("Benchmark mode could not be empty.")
 	default:
-		exit(2, "Unknown benchmark mode.")
+		fail("Unknown benchmark mode.")
+	}
+
+	for _, tag := range *resultTags {
+		if k, _, ok := strings.Cut(tag, "="); !ok || k == "" {
+			fail("Result tag should be in key=value format, got " + tag)
+		}
+	}
+
+	var chaosList []ChaosAction
+	for _, s := range *chaos {
+		a, err := ParseChaosAction(s)
+		if err != nil {
+			fail(err.Error())
+			continue
+		}
+		chaosList = append(chaosList, a)
+	}
+
+	if len(*matrixModes) != 0 || len(*matrixWorkers) != 0 || len(*matrixRates) != 0 {
+		if *input != "" {
+			fail("Matrix runs need transactions generated on the fly, input file could not be used.")
+		}
+		for _, m := range *matrixModes {
+			if BenchMode(m) != ModeWorker && BenchMode(m) != ModeRate {
+				fail("Unknown matrix benchmark mode " + m + ".")
+			}
+		}
+		for _, w := range *matrixWorkers {
+			if w <= 0 {
+				fail("Matrix workers count could not be empty or negative value.")
+			}
+		}
+		for _, r := range *matrixRates {
+			if r <= 0 {
+				fail("Matrix rate limit could not be empty or negative value.")
+			}
+		}
+		if *cooldown < 0 {
+			fail("Cooldown could not be negative value.")
+		}
+		if *settleTimeout <= 0 {
+			fail("Settle timeout could not be empty or negative value.")
+		}
+	}
+
+	if len(problems) != 0 {
+		exit(2, strings.Join(problems, "\n"))
 	}
 
 	// set RPC addresses (wrong parser in viper)
 	v.Set("rpcAddress", *rpcAddresses)
+	v.Set("stats-proc", *statsProcs)
+	v.Set("metrics", *metrics)
+	v.Set("metrics-name", *metricsNames)
+	v.Set("pprof", *pprofs)
+	v.Set("sink-influx", *sinkInflux)
+	v.Set("sink-webhook", *sinkWebhook)
+	v.Set("result-tag", *resultTags)
+	v.Set("matrix-mode", *matrixModes)
+	v.Set("matrix-workers", *matrixWorkers)
+	v.Set("matrix-rate", *matrixRates)
+	if v.GetString("sink-influx-token") == "" {
+		v.Set("sink-influx-token", os.Getenv("NEOBENCH_INFLUX_TOKEN"))
+	}
+	v.Set("chaos", chaosList)
+	v.Set("pprof-profiles", *pprofProfiles)
+	if len(*pprofAt) == 0 {
+		*pprofAt = []time.Duration{*timeLimit / 2}
+	}
+	v.Set("pprof-at", *pprofAt)
 
 	runtime.GOMAXPROCS(*concurrent)
 
 	return v
 }
+
+// NewGenerateOptions returns options of transactions generated on the fly,
+// senders are derived from the given first one.
+func NewGenerateOptions(v *viper.Viper, first *keys.PrivateKey) (BenchOptions, error) {
+	seed := v.GetUint64("gen-seed")
+	if seed == 0 {
+		seed = NewSeed()
+		v.Set("gen-seed", seed)
+	}
+
+	from := v.GetInt("gen-from")
+	if from < 1 {
+		return BenchOptions{}, fmt.Errorf("number of senders should be positive, got %d", from)
+	}
+
+	opts := BenchOptions{
+		TransferType: v.GetString("gen-type"),
+		TxCount:      uint64(v.GetInt("gen-count")),
+		ToCount:      v.GetInt("gen-to"),
+		Senders:      NewSenders(first, from, seed),
+		SignersCount: v.GetInt("gen-signers"),
+		WitnessScope: v.GetString("gen-scope"),
+
+		SendersDistribution:   v.GetString("gen-from-dist"),
+		ReceiversDistribution: v.GetString("gen-to-dist"),
+		Seed:                  seed,
+	}
+	return opts, opts.Validate()
+}
@@ -0,0 +1,264 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// ResultSummary is an overall result of the benchmark.
+	ResultSummary struct {
+		TxCount           int     `json:"tx_count"`
+		RPS               float64 `json:"rps"`
+		ErrCount          int32   `json:"err_count"`
+		ErrRate           float64 `json:"err_rate"`
+		TPS               float64 `json:"tps"`
+		DefaultMSPerBlock int     `json:"default_ms_per_block"`
+		GenRate           float64 `json:"gen_rate,omitempty"`
+		ResourceUsage
+	}
+
+	// Result is the benchmark summary and time series pushed to sinks.
+	Result struct {
+		Name      string            `json:"name"`
+		Tags      map[string]string `json:"tags"`
+		Start     time.Time         `json:"start"`
+		Summary   ResultSummary     `json:"summary"`
+		Blocks    []tpsInfo         `json:"blocks"`
+		Stats     []resStat         `json:"stats"`
+		Nodes     []nodeStats       `json:"nodes,omitempty"`
+		Metrics   []metricSeries    `json:"metrics,omitempty"`
+		Chaos     []chaosEvent      `json:"chaos,omitempty"`
+		Consensus []consensusInfo   `json:"consensus,omitempty"`
+	}
+
+	// ResultSink is a storage of benchmark results.
+	ResultSink interface {
+		Push(ctx context.Context, res *Result) error
+	}
+
+	influxSink struct {
+		url   string
+		token string
+		cli   *http.Client
+	}
+
+	webhookSink struct {
+		url string
+		cli *http.Client
+	}
+)
+
+// NewInfluxSink creates sink writing results to InfluxDB via HTTP API in line
+// protocol, url is a complete write endpoint, e.g.
+// http://127.0.0.1:8086/api/v2/write?org=neo&bucket=bench. Token is optional.
+func NewInfluxSink(url, token string) ResultSink {
+	return &influxSink{
+		url:   url,
+		token: token,
+		cli:   &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// NewWebhookSink creates sink posting results to url as JSON.
+func NewWebhookSink(url string) ResultSink {
+	return &webhookSink{
+		url: url,
+		cli: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Push posts results as JSON.
+func (s *webhookSink) Push(ctx context.Context, res *Result) error {
+	// JSON doesn't support NaN which is possible in summary, e.g. without stats.
+	r := *res
+	for _, f := range []*float64{&r.Summary.RPS, &r.Summary.ErrRate, &r.Summary.TPS, &r.Summary.GenRate,
+		&r.Summary.CPU, &r.Summary.Mem, &r.Summary.IOPS} {
+		if math.IsNaN(*f) || math.IsInf(*f, 0) {
+			*f = 0
+		}
+	}
+
+	data, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.cli, s.url, "application/json", nil, data)
+}
+
+// Push writes summary and time series of the results as separate measurements,
+// block timestamps are estimated from the start and blocks time deltas.
+func (s *influxSink) Push(ctx context.Context, res *Result) error {
+	var (
+		buf  bytes.Buffer
+		tags = lineTags(res.Tags, "name", res.Name)
+		ts   = func(ms float64) int64 {
+			return res.Start.Add(time.Duration(ms * float64(time.Millisecond))).UnixNano()
+		}
+	)
+
+	sum := res.Summary
+	writeLine(&buf, "neobench_summary", tags, []lineField{
+		{"tx_count", float64(sum.TxCount)},
+		{"rps", sum.RPS},
+		{"err_count", float64(sum.ErrCount)},
+		{"err_rate", sum.ErrRate},
+		{"tps", sum.TPS},
+		{"gen_rate", sum.GenRate},
+		{"cpu", sum.CPU},
+		{"mem", sum.Mem},
+		{"net_rx", sum.NetRx},
+		{"net_tx", sum.NetTx},
+		{"blk_read", sum.BlkRead},
+		{"blk_write", sum.BlkWrite},
+		{"iops", sum.IOPS},
+	}, time.Now().UnixNano())
+
+	var elapsed float64
+	for _, b := range res.Blocks {
+		elapsed += float64(b.DeltaTime)
+		writeLine(&buf, "neobench_block", tags, []lineField{
+			{"delta_time", float64(b.DeltaTime)},
+			{"tx_count", float64(b.TxCount)},
+			{"tps", b.TPS},
+		}, ts(elapsed))
+	}
+
+	for _, st := range res.Stats {
+		writeLine(&buf, "neobench_resources", tags, resourceFields(st.ResourceUsage), ts(st.MillisecondsFromStart))
+	}
+
+	for _, n := range res.Nodes {
+		nodeTags := tags + lineTags(map[string]string{"node": n.Name, "role": n.Role})
+		for _, st := range n.Stats {
+			writeLine(&buf, "neobench_node_resources", nodeTags, resourceFields(st.ResourceUsage), ts(st.MillisecondsFromStart))
+		}
+	}
+
+	for _, m := range res.Metrics {
+		metricTags := tags + lineTags(map[string]string{"endpoint": m.Endpoint, "metric": m.Name})
+		for _, v := range m.Values {
+			writeLine(&buf, "neobench_node_metric", metricTags, []lineField{{"value", v[1]}}, ts(v[0]))
+		}
+	}
+
+	for _, c := range res.Consensus {
+		writeLine(&buf, "neobench_consensus", tags, []lineField{
+			{"index", float64(c.Index)},
+			{"primary", float64(c.Primary)},
+			{"view", float64(c.View)},
+			{"delta_time", float64(c.DeltaTime)},
+			{"deviation", float64(c.Deviation)},
+		}, time.UnixMilli(int64(c.Timestamp)).UnixNano())
+	}
+
+	for _, ev := range res.Chaos {
+		chaosTags := tags + lineTags(map[string]string{"container": ev.Container, "action": ev.Action})
+		var failed float64
+		if ev.Error != "" {
+			failed = 1
+		}
+		writeLine(&buf, "neobench_chaos", chaosTags, []lineField{{"failed", failed}}, ts(ev.MillisecondsFromStart))
+	}
+
+	var header http.Header
+	if s.token != "" {
+		header = http.Header{"Authorization": []string{"Token " + s.token}}
+	}
+	return post(ctx, s.cli, s.url, "text/plain; charset=utf-8", header, buf.Bytes())
+}
+
+type lineField struct {
+	key   string
+	value float64
+}
+
+func resourceFields(u ResourceUsage) []lineField {
+	return []lineField{
+		{"cpu", u.CPU},
+		{"mem", u.Mem},
+		{"net_rx", u.NetRx},
+		{"net_tx", u.NetTx},
+		{"blk_read", u.BlkRead},
+		{"blk_write", u.BlkWrite},
+		{"iops", u.IOPS},
+	}
+}
+
+var lineEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+
+// lineTags returns sorted line protocol tags, each prefixed with comma, extra
+// pairs of key and value are added to the map.
+func lineTags(tags map[string]string, extra ...string) string {
+	all := make(map[string]string, len(tags)+len(extra)/2)
+	for k, v := range tags {
+		all[k] = v
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		all[extra[i]] = extra[i+1]
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		if all[k] != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString("," + lineEscaper.Replace(k) + "=" + lineEscaper.Replace(all[k]))
+	}
+	return sb.String()
+}
+
+func writeLine(w io.Writer, measurement, tags string, fields []lineField, ts int64) {
+	var sb strings.Builder
+	for _, f := range fields {
+		// Line protocol doesn't support NaN and infinities.
+		if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			continue
+		}
+		if sb.Len() != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(f.key + "=" + strconv.FormatFloat(f.value, 'f', -1, 64))
+	}
+	if sb.Len() == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s%s %s %d\n", measurement, tags, sb.String(), ts)
+}
+
+func post(ctx context.Context, cli *http.Client, url, contentType string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: unexpected status %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sinkRequest struct {
+	header http.Header
+	body   string
+}
+
+func newSinkServer(t *testing.T, status int) (*httptest.Server, <-chan sinkRequest) {
+	reqs := make(chan sinkRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs <- sinkRequest{header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("sink error"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, reqs
+}
+
+func testResult() *Result {
+	return &Result{
+		Name:  "NEO-4x1",
+		Tags:  map[string]string{"release": "0.117.0", "host name": "a,b"},
+		Start: time.UnixMilli(1_000_000),
+		Summary: ResultSummary{
+			TxCount: 10,
+			RPS:     math.NaN(),
+			TPS:     5,
+		},
+		Blocks: []tpsInfo{{DeltaTime: 1000, TxCount: 5, TPS: 5}, {DeltaTime: 1000, TxCount: 5, TPS: 5}},
+		Chaos:  []chaosEvent{{MillisecondsFromStart: 500, Container: "node", Action: ChaosPause, Error: "failed"}},
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	srv, reqs := newSinkServer(t, http.StatusOK)
+
+	if err := NewWebhookSink(srv.URL).Push(context.Background(), testResult()); err != nil {
+		t.Fatal(err)
+	}
+	req := <-reqs
+	if ct := req.header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+
+	var res Result
+	if err := json.Unmarshal([]byte(req.body), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Name != "NEO-4x1" || res.Summary.TxCount != 10 || res.Summary.RPS != 0 || len(res.Blocks) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestInfluxSink(t *testing.T) {
+	srv, reqs := newSinkServer(t, http.StatusNoContent)
+
+	if err := NewInfluxSink(srv.URL, "secret").Push(context.Background(), testResult()); err != nil {
+		t.Fatal(err)
+	}
+	req := <-reqs
+	if auth := req.header.Get("Authorization"); auth != "Token secret" {
+		t.Fatalf("unexpected authorization %s", auth)
+	}
+
+	lines := strings.Split(strings.TrimSpace(req.body), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got:\n%s", req.body)
+	}
+	tags := `,host\ name=a\,b,name=NEO-4x1,release=0.117.0 `
+	if !strings.HasPrefix(lines[0], "neobench_summary"+tags) || strings.Contains(lines[0], "rps=") {
+		t.Fatalf("unexpected summary %s", lines[0])
+	}
+	for i, want := range []string{
+		"neobench_block" + tags + "delta_time=1000,tx_count=5,tps=5 1001000000000",
+		"neobench_block" + tags + "delta_time=1000,tx_count=5,tps=5 1002000000000",
+		"neobench_chaos" + tags[:len(tags)-1] + ",action=pause,container=node failed=1 1000500000000",
+	} {
+		if lines[i+1] != want {
+			t.Errorf("expected\n%s\ngot\n%s", want, lines[i+1])
+		}
+	}
+}
+
+func TestSinkStatus(t *testing.T) {
+	srv, _ := newSinkServer(t, http.StatusBadRequest)
+
+	err := NewWebhookSink(srv.URL).Push(context.Background(), testResult())
+	if err == nil || !strings.Contains(err.Error(), "sink error") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Topology describes benchmarked network: consensus nodes, RPC nodes the
+	// bench sends transactions to and common container settings.
+	Topology struct {
+		// Validators are consensus nodes in the standby validators order.
+		Validators []NodeSpec `yaml:"validators"`
+		// RPC are non-consensus nodes serving bench requests, the only
+		// validator serves them if there are no RPC nodes in a single node
+		// network.
+		RPC []NodeSpec `yaml:"rpc"`
+		// Logger is a Docker logging driver of nodes.
+		Logger string `yaml:"logger"`
+		// TC are arguments of 'tc qdisc netem' applied to validators.
+		TC string `yaml:"tc"`
+		// Network are per-node and per-link profiles changed during the run,
+		// nodes are referred to by NodeNames.
+		Network []NetStage     `yaml:"network"`
+		Images  TopologyImages `yaml:"images"`
+	}
+
+	// NodeSpec describes a single node.
+	NodeSpec struct {
+		// Type is either go or sharp.
+		Type string `yaml:"type"`
+	}
+
+	// TopologyImages are Docker images of nodes and the bench.
+	TopologyImages struct {
+		Go    string `yaml:"go"`
+		Sharp string `yaml:"sharp"`
+		Bench string `yaml:"bench"`
+	}
+)
+
+// Node types.
+const (
+	NodeGo    = "go"
+	NodeSharp = "sharp"
+	// NodeMixed is a network of C# nodes followed by Go nodes.
+	NodeMixed = "mixed"
+)
+
+// Default images built by the Makefile.
+const (
+	DefaultGoImage    = "registry.nspcc.ru/neo-bench/neo-go:bench"
+	DefaultSharpImage = "registry.nspcc.ru/neo-bench/neo-sharp:bench"
+	DefaultBenchImage = "registry.nspcc.ru/neo-bench/neo-bench:bench"
+)
+
+// NewTopology returns topology of the given number of validators of the given
+// type (go, sharp or mixed) with rpcCount RPC nodes of rpcType, just like
+// runner.sh selects them. Mixed network consists of validators/2 C# nodes
+// followed by Go nodes, mixed RPC nodes are Go ones.
+func NewTopology(validators int, nodeType string, rpcCount int, rpcType string) (*Topology, error) {
+	if validators < 1 {
+		return nil, fmt.Errorf("invalid validators count: %d", validators)
+	}
+
+	t := &Topology{Logger: "none"}
+	for i := range validators {
+		switch nodeType {
+		case NodeGo, NodeSharp:
+			t.Validators = append(t.Validators, NodeSpec{Type: nodeType})
+		case NodeMixed:
+			typ := NodeGo
+			if i < validators/2 {
+				typ = NodeSharp
+			}
+			t.Validators = append(t.Validators, NodeSpec{Type: typ})
+		default:
+			return nil, fmt.Errorf("unknown node type: %s", nodeType)
+		}
+	}
+
+	if rpcType == NodeMixed {
+		rpcType = NodeGo
+	}
+	for range rpcCount {
+		t.RPC = append(t.RPC, NodeSpec{Type: rpcType})
+	}
+
+	return t, t.Validate()
+}
+
+// LoadTopology reads topology from the given path.
+func LoadTopology(path string) (*Topology, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read topology: %w", err)
+	}
+	defer f.Close()
+
+	var t Topology
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not decode topology %s: %w", path, err)
+	}
+	if t.Logger == "" {
+		t.Logger = "none"
+	}
+
+	return &t, t.Validate()
+}
+
+// Validate checks topology consistency.
+func (t *Topology) Validate() error {
+	var errs []error
+	if len(t.Validators) == 0 {
+		errs = append(errs, errors.New("no validators"))
+	}
+	for i, n := range t.Validators {
+		if n.Type != NodeGo && n.Type != NodeSharp {
+			errs = append(errs, fmt.Errorf("validator #%d: unknown node type %q", i, n.Type))
+		}
+	}
+	for i, n := range t.RPC {
+		if n.Type != NodeGo && n.Type != NodeSharp {
+			errs = append(errs, fmt.Errorf("RPC node #%d: unknown node type %q", i, n.Type))
+		}
+	}
+	if len(t.Validators) == 1 && len(t.RPC) != 0 {
+		errs = append(errs, errors.New("RPC nodes are not supported in a single node network"))
+	}
+	if t.TC != "" && len(t.Network) != 0 {
+		errs = append(errs, errors.New("tc and network profiles can't be used together"))
+	}
+	if err := validateNetwork(t.Network, t.NodeNames()); err != nil {
+		errs = append(errs, err)
+	}
+	switch t.Logger {
+	case "none", "json-file", "syslog", "journald":
+	default:
+		errs = append(errs, fmt.Errorf("unknown logger: %s", t.Logger))
+	}
+	return errors.Join(errs...)
+}
+
+// NodeNames returns names of the topology nodes: validators are named by
+// NodeName, RPC nodes by their container names (go-node, go-node-2, etc.).
+func (t *Topology) NodeNames() []string {
+	var names []string
+	for i := range t.Validators {
+		names = append(names, NodeName(i))
+	}
+	for i, n := range t.RPC {
+		names = append(names, rpcNodeName(n, i))
+	}
+	return names
+}
+
+// rpcNodeName returns container name of i-th RPC node.
+func rpcNodeName(n NodeSpec, i int) string {
+	name := n.Type + "-node"
+	if i > 0 {
+		name += "-" + strconv.Itoa(i+1)
+	}
+	return name
+}
+
+// nodeNames are the names of validators which wallets and configurations are
+// stored in .docker/ir.
+var nodeNames = []string{"one", "two", "three", "four", "five", "six", "seven"}
+
+// NodeName returns the name of i-th validator used in its wallet, configuration
+// file and container names. Validators beyond the seventh one are named by
+// their number.
+func NodeName(i int) string {
+	if i < len(nodeNames) {
+		return nodeNames[i]
+	}
+	return strconv.Itoa(i + 1)
+}
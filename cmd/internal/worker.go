@@ -10,13 +10,14 @@ import (
 	"time"
 
 	"github.com/nspcc-dev/neo-go/pkg/core/block"
+	"github.com/nspcc-dev/neo-go/pkg/crypto/keys"
 )
 
 type (
 	// Worker interface.
 	Worker interface {
 		Wait()
-		Prepare(ctx context.Context, vote bool, opts BenchOptions)
+		Prepare(ctx context.Context, committee []*keys.PrivateKey, vote bool, opts BenchOptions)
 		Sender(ctx context.Context)
 		Parser(ctx context.Context, block *block.Block)
 	}
@@ -48,6 +49,8 @@ type (
 		errReporter     func(cnt int32)
 		rpsReporter     func(rps float64)
 		tpsReporter     func(deltaTime uint64, txCount int, tps float64)
+		blockReporter   func(h *block.Header, deltaTime uint64)
+		sendReporter    func(err error)
 		stop            context.CancelFunc
 	}
 
@@ -143,6 +146,20 @@ func WorkerTPSReporter(reporter func(deltaTime uint64, txCount int, v float64))
 	}
 }
 
+// WorkerBlockReporter sets method that would be used to report every parsed
+// block header along with the time since the previous block, empty blocks are
+// reported as well.
+func WorkerBlockReporter(reporter func(h *block.Header, deltaTime uint64)) WorkerOption {
+	return func(p *doerParams) {
+		// ignore empty func
+		if reporter == nil {
+			return
+		}
+
+		p.blockReporter = reporter
+	}
+}
+
 // WorkerErrReporter sets method that would be used to report errors count while send TX to RPC.
 func WorkerErrReporter(reporter func(v int32)) WorkerOption {
 	return func(p *doerParams) {
@@ -167,15 +184,30 @@ func WorkerCntReporter(reporter func(v int32)) WorkerOption {
 	}
 }
 
+// WorkerSendReporter sets method that would be used to report result of every
+// Tx submission, nil error means Tx is sent.
+func WorkerSendReporter(reporter func(err error)) WorkerOption {
+	return func(p *doerParams) {
+		// ignore empty func
+		if reporter == nil {
+			return
+		}
+
+		p.sendReporter = reporter
+	}
+}
+
 // NewWorkers creates new worker manager.
 func NewWorkers(opts ...WorkerOption) (Worker, error) {
 	p := doerParams{
 		// set defaults:
-		cntReporter: func(_ int32) {},
-		errReporter: func(_ int32) {},
-		rpsReporter: func(_ float64) {},
-		tpsReporter: func(_ uint64, _ int, _ float64) {},
-		stop:        func() { log.Fatal("default stopper") },
+		cntReporter:   func(_ int32) {},
+		errReporter:   func(_ int32) {},
+		rpsReporter:   func(_ float64) {},
+		tpsReporter:   func(_ uint64, _ int, _ float64) {},
+		blockReporter: func(_ *block.Header, _ uint64) {},
+		sendReporter:  func(_ error) {},
+		stop:          func() { log.Fatal("default stopper") },
 	}
 
 	for i := range opts {
@@ -187,13 +219,13 @@ func NewWorkers(opts ...WorkerOption) (Worker, error) {
 		return nil, errors.New("workers count could not be empty")
 	case p.dump == nil:
 		return nil, errors.New("dump could not be empty")
-	case p.dump.TransactionsQueue.Len() < 1:
+	case p.dump.count() < 1:
 		return nil, errors.New("txs could not be empty")
 	case p.cli == nil:
 		return nil, errors.New("blockchain client count could not be empty")
 	}
 
-	ln := int(p.dump.TransactionsQueue.Len())
+	ln := p.dump.count()
 
 	switch p.mode {
 	case ModeRate:
@@ -217,36 +249,45 @@ func NewWorkers(opts ...WorkerOption) (Worker, error) {
 
 // idx defines the order of the transaction being sent and can be more than overall transactions count, because retransmission is supported.
 func (d *doer) worker(ctx context.Context, idx *atomic.Int64, start time.Time) {
+	// Sending is limited by the context while waiting for transactions is
+	// limited by the time limit as well.
+	limitCtx, cancel := context.WithTimeout(ctx, d.timeLimit)
+	defer cancel()
+
 	var (
-		done           = ctx.Done()
-		timer          = time.NewTimer(d.timeLimit)
+		done           = limitCtx.Done()
 		localTxCounter int64
+		// retry is a transaction rejected due to mempool OOM, it's sent
+		// again before taking the next one.
+		retry string
 	)
+	defer func() {
+		if retry != "" {
+			log.Println("transaction rejected due to mempool OOM is dropped")
+			d.countErr.Add(1)
+			d.sendReporter(ErrTxDropped)
+		}
+	}()
 
 loop:
 	for {
 		select {
 		case <-done:
 			return
-		case <-timer.C:
-			return
 		default:
 			idx.Add(1)
-			if d.dump.TransactionsQueue.Len() == 0 {
-				return
-			}
-			tx, err := d.dump.TransactionsQueue.Get()
-			if err != nil {
-				log.Fatalf("cannot dequeue transaction: %s", err)
-				return
+			tx := retry
+			if tx == "" {
+				var ok bool
+				if tx, ok = d.dump.next(limitCtx); !ok {
+					return
+				}
 			}
-			if err := d.cli.SendTX(ctx, tx.(string)); err != nil {
+			retry = ""
+			if err := d.cli.SendTX(ctx, tx); err != nil {
+				d.sendReporter(err)
 				if errors.Is(err, ErrMempoolOOM) {
-					err := d.dump.TransactionsQueue.Put(tx.(string))
-					if err != nil {
-						log.Printf("failed to re-enqueue transaction: %s\n", err)
-						d.countErr.Add(1)
-					}
+					retry = tx
 					time.Sleep(d.mempoolOOMDelay)
 				} else {
 					d.countErr.Add(1)
@@ -256,6 +297,7 @@ loop:
 				// return
 			}
 
+			d.sendReporter(nil)
 			since := time.Since(start)
 			count := d.countTxs.Add(1)
 			localTxCounter++
@@ -373,6 +415,7 @@ func (d *doer) parse(ctx context.Context, startBlock int, lastTime *uint64) (las
 
 			// update last block timestamp
 			*lastTime = blk.Timestamp
+			d.blockReporter(&blk.Header, dt)
 
 			// do not add zero TPS in case if there were no non-empty blocks yet
 			if tps == 0 {
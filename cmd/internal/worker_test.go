@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Workiva/go-datastructures/queue"
+	"github.com/spf13/viper"
+)
+
+// newOOMServer returns RPC server rejecting every transaction due to mempool
+// OOM the given number of times before accepting it.
+func newOOMServer(t *testing.T, rejects int) (*RPCClient, func() []string) {
+	var (
+		mtx      sync.Mutex
+		received []string
+		attempts = make(map[string]int)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []string `json:"params"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil || len(req.Params) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		tx := req.Params[0]
+
+		mtx.Lock()
+		received = append(received, tx)
+		attempts[tx]++
+		oom := rejects < 0 || attempts[tx] <= rejects
+		mtx.Unlock()
+
+		if oom {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-500,"message":"OutOfMemory"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"hash":"0x` + strings.Repeat("1", 64) + `"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	v := viper.New()
+	v.Set("rpcAddress", []string{strings.TrimPrefix(srv.URL, "http://")})
+	return NewRPCClient(v, 1), func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+func newTestWorker(t *testing.T, cli *RPCClient, limit time.Duration, txs ...string) (*doer, func() []error) {
+	dump := &Dump{TransactionsQueue: queue.NewRingBuffer(uint64(len(txs)))}
+	for _, tx := range txs {
+		if err := dump.TransactionsQueue.Put(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mtx     sync.Mutex
+		reports []error
+	)
+	w, err := NewWorkers(
+		WorkersCount(1),
+		WorkerDump(dump),
+		WorkerBlockchainClient(cli),
+		WorkerTimeLimit(limit),
+		WorkerMempoolOOMDelay(time.Millisecond),
+		WorkerSendReporter(func(err error) {
+			mtx.Lock()
+			reports = append(reports, err)
+			mtx.Unlock()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.(*doer), func() []error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return slices.Clone(reports)
+	}
+}
+
+func TestWorkerMempoolOOMRetry(t *testing.T) {
+	cli, received := newOOMServer(t, 2)
+	d, reports := newTestWorker(t, cli, time.Minute, "a", "b")
+
+	d.worker(context.Background(), new(atomic.Int64), time.Now())
+
+	// Rejected transaction is sent again before the next one.
+	if got, want := received(), []string{"a", "a", "a", "b", "b", "b"}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	var oom, sent int
+	for _, err := range reports() {
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, ErrMempoolOOM):
+			oom++
+		default:
+			t.Fatalf("unexpected report %v", err)
+		}
+	}
+	if oom != 4 || sent != 2 || d.countTxs.Load() != 2 || d.countErr.Load() != 0 {
+		t.Fatalf("unexpected counters: %d OOM, %d sent, %d txs, %d errors", oom, sent, d.countTxs.Load(), d.countErr.Load())
+	}
+}
+
+func TestWorkerMempoolOOMDrop(t *testing.T) {
+	cli, received := newOOMServer(t, -1)
+	d, reports := newTestWorker(t, cli, 100*time.Millisecond, "a", "b")
+
+	d.worker(context.Background(), new(atomic.Int64), time.Now())
+
+	for _, tx := range received() {
+		if tx != "a" {
+			t.Fatalf("transaction %s is sent while a isn't accepted", tx)
+		}
+	}
+	var dropped int
+	for _, err := range reports() {
+		if errors.Is(err, ErrTxDropped) {
+			dropped++
+		}
+	}
+	if dropped != 1 {
+		t.Fatalf("expected the single drop, got %d", dropped)
+	}
+}
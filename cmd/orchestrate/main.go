@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/nspcc-dev/neo-bench/internal"
+)
+
+var (
+	topologyFile = flag.String("topology", "", "Path to YAML topology file, flags below are used to describe topology if not set.")
+	validators   = flag.Int("validators", 4, "Consensus node count.")
+	nodes        = flag.String("nodes", internal.NodeGo, "Consensus node type: go, sharp or mixed.")
+	rpcType      = flag.String("rpc", "", "RPC node type, the same as -nodes if not set.")
+	rpcCount     = flag.Int("rpc-count", -1, "RPC node count, 2 for 7 nodes network, 1 for other multinode networks and 0 for a single node if not set.")
+	tc           = flag.String("tc", "", "Arguments to pass to 'tc qdisc netem' inside validator containers.")
+	logger       = flag.String("log", "none", "Container logging driver: none, json-file, syslog or journald.")
+
+	dockerDir     = flag.String("dir", "../.docker", "Path to the directory with nodes configurations.")
+	out           = flag.String("out", "../.docker/ir/out", "Path to the directory reports are written to.")
+	dump          = flag.String("dump", "", "Path to transactions dump to pass to the bench, transactions are generated on the fly if not set.")
+	healthTimeout = flag.Duration("health-timeout", 5*time.Minute, "Time limit for nodes to become healthy.")
+	keep          = flag.Bool("keep", false, "Keep the cluster running after the bench.")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(internal.NewGracefulContext()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run brings up the cluster, runs the bench with the remaining arguments and
+// tears the cluster down.
+func run(ctx context.Context) (err error) {
+	topo, err := topology()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := internal.NewCluster(topo,
+		internal.ClusterDir(*dockerDir),
+		internal.ClusterHealthTimeout(*healthTimeout))
+	if err != nil {
+		return err
+	}
+
+	if !*keep {
+		defer func() {
+			// Cluster is removed even if the run is interrupted.
+			downCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			err = errors.Join(err, cluster.Down(downCtx)) //nolint:contextcheck // contextcheck: Non-inherited new context, use function like `context.WithXXX` instead
+		}()
+	}
+
+	if err := cluster.Up(ctx); err != nil {
+		return err
+	}
+
+	return cluster.RunBench(ctx, internal.BenchRun{
+		Out:  *out,
+		Dump: *dump,
+		Args: flag.Args(),
+	})
+}
+
+func topology() (*internal.Topology, error) {
+	if *topologyFile != "" {
+		return internal.LoadTopology(*topologyFile)
+	}
+
+	count := *rpcCount
+	if count < 0 {
+		switch *validators {
+		case 1:
+			count = 0
+		case 7:
+			count = 2
+		default:
+			count = 1
+		}
+	}
+
+	typ := *rpcType
+	if typ == "" {
+		typ = *nodes
+	}
+
+	topo, err := internal.NewTopology(*validators, *nodes, count, typ)
+	if err != nil {
+		return nil, err
+	}
+	topo.TC = *tc
+	topo.Logger = *logger
+	return topo, topo.Validate()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nspcc-dev/neo-bench/internal"
+)
+
+var (
+	rpcAddress = flag.String("a", "127.0.0.1:20331", "RPC address of the node to prepare chain with.")
+	inp        = flag.String("in", "", "Path to transactions dump to take senders from.")
+	out        = flag.String("out", "", "Path to write the resulting chain state to (JSON).")
+	vote       = flag.Bool("vote", false, "Vote for candidates.")
+	keysConfig = flag.String("keys", "", "Path to keys config with committee and the first sender keys.")
+
+	fromCount = flag.Int("from", 1, "Amount of tx senders, used if dump isn't specified.")
+	seed      = flag.Uint64("seed", 0, "Seed senders were derived from by gen or bench, required if dump isn't specified and -from is more than 1.")
+)
+
+func main() {
+	flag.Parse()
+
+	ctx := internal.NewGracefulContext()
+
+	ks, err := internal.LoadKeys(*keysConfig)
+	if err != nil {
+		log.Fatalf("Could not load keys: %v", err)
+	}
+
+	var opts internal.BenchOptions
+	if *inp != "" {
+		opts, err = internal.ReadDumpOptions(*inp)
+		if err != nil {
+			log.Fatalf("Could not read dump options: %v", err)
+		}
+	} else {
+		if *fromCount < 1 {
+			log.Fatalf("Amount of senders should be positive, got %d", *fromCount)
+		}
+		// Unlike gen and bench, zero can't mean a random seed here, senders
+		// should be the same as the ones transactions are generated for.
+		if *fromCount > 1 && *seed == 0 {
+			log.Fatalf("Seed should be set explicitly for %d senders", *fromCount)
+		}
+		opts.Senders = internal.NewSenders(ks.Sender, *fromCount, *seed)
+	}
+
+	res, err := internal.PrepareChain(ctx, "http://"+*rpcAddress, ks.Committee, *vote, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		return
+	}
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		log.Fatalf("Could not marshal chain state: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatalf("Could not write chain state: %v", err)
+	}
+}
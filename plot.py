@@ -94,18 +94,21 @@ def plot_data(path):
                 elif defaultMSPerBlock != msPerBlock:
                     print("Error: file {} has bad DefaultMSPerBlock value. Please, check that all nodes configurations has the same MillisecondPerBlock value.".format(file[0]))
                     exit(1)
-                for i in range(12, len(lines)):
+                statsStart = next(i for i in range(len(lines)) if lines[i].startswith('MillisecondsFromStart')) + 1
+                for i in range(statsStart, len(lines)):
                     line = lines[i]
                     cpumem = line.split('%,')
                     if len(cpumem) == 2:
                         millisecondsFromStartcpu = cpumem[0].split(', ')
                         secondsFromStartFile.append(float(millisecondsFromStartcpu[0])/1000)
                         cpuFile.append(float(millisecondsFromStartcpu[1]))
-                        memFile.append(float(cpumem[1].strip(' ').strip('\n').strip('MB')))
+                        memFile.append(float(cpumem[1].split(',')[0].strip(' ').strip('\n').strip('MB')))
                     else:
                         tpsStart = i + 2
                         break
                 for i in range(tpsStart, len(lines)):
+                    if lines[i].strip() == '':
+                        break
                     tpsFile.append(float(lines[i].split(', ')[2]))
                     tpbFile.append(int(lines[i].split(', ')[1]))
                     blockDeltaTimeFile.append(int(lines[i].split(', ')[0]))
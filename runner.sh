@@ -18,6 +18,7 @@ export NEOBENCH_TYPE=${NEOBENCH_TYPE:-NEO}
 export NEOBENCH_FROM_COUNT=${NEOBENCH_FROM_COUNT:-1}
 export NEOBENCH_TC=${NEOBENCH_TC:-}
 export NEOBENCH_TO_COUNT=${NEOBENCH_TO_COUNT:-1}
+export NEOBENCH_SEED=${NEOBENCH_SEED:-0}
 export NEOBENCH_VALIDATOR_COUNT=${NEOBENCH_VALIDATOR_COUNT:-4}
 
 show_help() {
@@ -284,9 +285,13 @@ if [ -n "$NEOBENCH_VOTE" ]; then
 	ARGS+=(--vote)
 fi
 
+if GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null); then
+	ARGS+=(--result-tag "git_commit=$GIT_COMMIT")
+fi
+
 make prepare
 if [ "$EXTERNAL_NETWORK" = true ]; then
-      ARGS+=(-i "./.docker/build/dump.$NEOBENCH_TYPE.$NEOBENCH_FROM_COUNT.$NEOBENCH_TO_COUNT.txs" --disable-stats)
+      ARGS+=(-i "./.docker/build/dump.$NEOBENCH_TYPE.$NEOBENCH_FROM_COUNT.$NEOBENCH_TO_COUNT.$NEOBENCH_SEED.txs" --disable-stats)
       ./cmd/bin/bench -o "$OUTPUT" "${ARGS[@]}"&
       pid=$!
       wait $pid